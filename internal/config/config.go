@@ -0,0 +1,235 @@
+// Package config reads claude-mri's settings from its config file and the
+// command line. Flags override the file.
+package config
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/natdempk/claude-mri/internal/data"
+)
+
+// Config holds every setting. Pointer fields are nil when unset, so the
+// file and the flags can each leave them at their defaults.
+type Config struct {
+	// Projects directories or tarballs to browse; "label=path" names a root.
+	// Empty means ~/.claude/projects.
+	Paths     []string `json:"paths,omitempty"`
+	Sort      string   `json:"sort,omitempty"` // "name" or "recent"
+	Follow    *bool    `json:"follow,omitempty"`
+	TreeWidth int      `json:"treeWidth,omitempty"`
+	Theme     string   `json:"theme,omitempty"`    // "auto", "dark" or "light"
+	DebugLog  string   `json:"debugLog,omitempty"` // "off" disables the log
+	Redact    *bool    `json:"redact,omitempty"`
+	// Poll forces the polling watcher at this interval (e.g. "2s"), for
+	// filesystems that deliver no change events
+	Poll string `json:"poll,omitempty"`
+}
+
+// Defaults for settings left unset
+const (
+	DefaultSort      = "name"
+	DefaultTreeWidth = 40
+	DefaultTheme     = "auto"
+	MinTreeWidth     = 20
+)
+
+// DefaultPath returns the config file under the user config directory
+// ($XDG_CONFIG_HOME/claude-mri on Linux)
+func DefaultPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "claude-mri", "config.json")
+}
+
+// Load reads a config file. A missing file yields an empty Config.
+func Load(path string) (Config, error) {
+	var cfg Config
+	raw, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return cfg, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Parse reads the config file named by --config, or the default one, and
+// applies the command-line flags over it. Usage and errors are written to
+// output; -h yields flag.ErrHelp.
+func Parse(args []string, output io.Writer) (Config, error) {
+	fs := flag.NewFlagSet("claude-mri", flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.Usage = func() {
+		fmt.Fprintf(output, "Usage: claude-mri [flags]\n\nFlags override %s.\n\n", DefaultPath())
+		fs.PrintDefaults()
+	}
+
+	var flags Config
+	var paths stringList
+	var follow, redact bool
+	configPath := fs.String("config", DefaultPath(), "config file")
+	fs.Var(&paths, "path", "projects directory or tarball, optionally `label=path`; repeat for several roots")
+	fs.StringVar(&flags.Sort, "sort", "", "initial sort order: name or recent (default name)")
+	fs.BoolVar(&follow, "follow", true, "follow new messages as they arrive")
+	fs.IntVar(&flags.TreeWidth, "tree-width", 0, fmt.Sprintf("tree pane width in columns (default %d)", DefaultTreeWidth))
+	fs.StringVar(&flags.Theme, "theme", "", "color theme: auto, dark or light (default auto)")
+	fs.StringVar(&flags.DebugLog, "debug-log", "", "debug log file, or off (default ~/claude-mri-debug.log)")
+	fs.BoolVar(&redact, "redact", true, "mask secrets in transcripts")
+	fs.StringVar(&flags.Poll, "poll", "", "poll for changes at this `interval` (e.g. 2s) instead of using filesystem events")
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+	if fs.NArg() > 0 {
+		return Config{}, fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	cfg, err := Load(*configPath)
+	if err != nil {
+		return Config{}, err
+	}
+
+	// Only flags given on the command line override the file
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "path":
+			cfg.Paths = paths
+		case "sort":
+			cfg.Sort = flags.Sort
+		case "follow":
+			cfg.Follow = &follow
+		case "tree-width":
+			cfg.TreeWidth = flags.TreeWidth
+		case "theme":
+			cfg.Theme = flags.Theme
+		case "debug-log":
+			cfg.DebugLog = flags.DebugLog
+		case "redact":
+			cfg.Redact = &redact
+		case "poll":
+			cfg.Poll = flags.Poll
+		}
+	})
+	return cfg, cfg.Validate()
+}
+
+// Validate checks settings that have a fixed set of values
+func (c Config) Validate() error {
+	switch c.Sort {
+	case "", "name", "recent":
+	default:
+		return fmt.Errorf("sort must be name or recent, not %q", c.Sort)
+	}
+	switch c.Theme {
+	case "", "auto", "dark", "light":
+	default:
+		return fmt.Errorf("theme must be auto, dark or light, not %q", c.Theme)
+	}
+	if c.TreeWidth != 0 && c.TreeWidth < MinTreeWidth {
+		return fmt.Errorf("tree width must be at least %d", MinTreeWidth)
+	}
+	if _, err := c.PollInterval(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Roots returns the configured roots, or the default ones when no path is
+// set. "label=path" names a root; otherwise it is named after its folder.
+// A label holds no path separator, so paths containing "=" stay whole.
+func (c Config) Roots() []data.Root {
+	if len(c.Paths) == 0 {
+		return data.DefaultRoots()
+	}
+	roots := make([]data.Root, 0, len(c.Paths))
+	for _, p := range c.Paths {
+		var root data.Root
+		if label, path, ok := strings.Cut(p, "="); ok && label != "" && !strings.ContainsAny(label, `/\`) {
+			root = data.Root{Label: label, Path: expandHome(path)}
+		} else {
+			root = data.Root{Path: expandHome(p)}
+		}
+		roots = append(roots, root)
+	}
+	return data.UniqueRoots(roots)
+}
+
+// SortRecent reports whether the tree starts sorted by recent activity
+func (c Config) SortRecent() bool {
+	return c.Sort == "recent"
+}
+
+// FollowMode returns the follow setting, on by default
+func (c Config) FollowMode() bool {
+	return c.Follow == nil || *c.Follow
+}
+
+// Redacting returns the redaction setting, on by default
+func (c Config) Redacting() bool {
+	return c.Redact == nil || *c.Redact
+}
+
+// Width returns the tree pane width
+func (c Config) Width() int {
+	if c.TreeWidth == 0 {
+		return DefaultTreeWidth
+	}
+	return c.TreeWidth
+}
+
+// DebugLogPath returns where to write the debug log, or "" if disabled
+func (c Config) DebugLogPath() string {
+	switch c.DebugLog {
+	case "off":
+		return ""
+	case "":
+		home, _ := os.UserHomeDir()
+		return filepath.Join(home, "claude-mri-debug.log")
+	}
+	return expandHome(c.DebugLog)
+}
+
+// PollInterval returns the forced polling interval, or 0 to use filesystem
+// events
+func (c Config) PollInterval() (time.Duration, error) {
+	if c.Poll == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(c.Poll)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("poll must be a positive duration such as 2s, not %q", c.Poll)
+	}
+	return d, nil
+}
+
+// expandHome replaces a leading ~/ with the home directory
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return path
+}
+
+// stringList collects a repeatable flag
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParse_FlagsOverrideFile(t *testing.T) {
+	path := writeConfig(t, `{"paths": ["work=/mnt/work/projects"], "sort": "recent", "follow": false, "treeWidth": 50, "theme": "light", "redact": false}`)
+
+	cfg, err := Parse([]string{"--config", path, "--tree-width", "60", "--redact"}, io.Discard)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if cfg.Width() != 60 || !cfg.Redacting() {
+		t.Errorf("expected flags to override the file, got width %d redact %v", cfg.Width(), cfg.Redacting())
+	}
+	if !cfg.SortRecent() || cfg.FollowMode() || cfg.Theme != "light" {
+		t.Errorf("expected file settings kept, got %+v", cfg)
+	}
+	roots := cfg.Roots()
+	if len(roots) != 1 || roots[0].Label != "work" || roots[0].Path != "/mnt/work/projects" {
+		t.Errorf("unexpected roots %+v", roots)
+	}
+
+	cfg, err = Parse([]string{"--config", path, "--path", "/a/projects", "--path", "theirs=/b/projects", "--follow=true"}, io.Discard)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	roots = cfg.Roots()
+	if len(roots) != 2 || roots[0].Label != "projects" || roots[1].Label != "theirs" || !cfg.FollowMode() {
+		t.Errorf("expected --path to replace the file's paths, got %+v", roots)
+	}
+}
+
+func TestRoots_PathWithEquals(t *testing.T) {
+	cfg := Config{Paths: []string{"/mnt/a=b/projects", "work=/mnt/c=d/projects"}}
+	roots := cfg.Roots()
+	want := []string{"/mnt/a=b/projects", "/mnt/c=d/projects"}
+	if len(roots) != len(want) {
+		t.Fatalf("expected %d roots, got %+v", len(want), roots)
+	}
+	for i, path := range want {
+		if roots[i].Path != path {
+			t.Errorf("root %d: expected path %q, got %q", i, path, roots[i].Path)
+		}
+	}
+	if roots[0].Label != "projects" || roots[1].Label != "work" {
+		t.Errorf("unexpected labels %+v", roots)
+	}
+}
+
+func TestParse_Defaults(t *testing.T) {
+	cfg, err := Parse([]string{"--config", filepath.Join(t.TempDir(), "missing.json")}, io.Discard)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if cfg.Width() != DefaultTreeWidth || !cfg.FollowMode() || !cfg.Redacting() || cfg.SortRecent() {
+		t.Errorf("unexpected defaults %+v", cfg)
+	}
+	if d, _ := cfg.PollInterval(); d != 0 {
+		t.Errorf("expected native events by default, got poll %v", d)
+	}
+	if cfg.DebugLogPath() == "" {
+		t.Error("expected a default debug log")
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.json")
+	for _, args := range [][]string{
+		{"--sort", "size"},
+		{"--theme", "neon"},
+		{"--tree-width", "5"},
+		{"--poll", "often"},
+		{"stray"},
+	} {
+		if _, err := Parse(append([]string{"--config", missing}, args...), io.Discard); err == nil {
+			t.Errorf("expected error for %v", args)
+		}
+	}
+	if _, err := Parse([]string{"--config", writeConfig(t, `{"sort": `)}, io.Discard); err == nil {
+		t.Error("expected error for a broken config file")
+	}
+	if _, err := Parse([]string{"-h"}, io.Discard); !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("expected ErrHelp, got %v", err)
+	}
+
+	cfg, err := Parse([]string{"--config", missing, "--poll", "500ms", "--debug-log", "off"}, io.Discard)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if d, _ := cfg.PollInterval(); d != 500*time.Millisecond || cfg.DebugLogPath() != "" {
+		t.Errorf("expected polling and no debug log, got %+v", cfg)
+	}
+}
@@ -0,0 +1,63 @@
+package data
+
+import "time"
+
+var (
+	// ActiveWindow is how recently a session must have been written to
+	// count as live
+	ActiveWindow = 30 * time.Second
+	// ToolWaitWindow bounds how long a turn waiting on a tool result keeps
+	// a quiet session live, so interrupted sessions eventually go idle
+	ToolWaitWindow = 10 * time.Minute
+)
+
+// LastActivity returns when the session was last written: the file's
+// modification time or the newest entry's timestamp, whichever is later
+func (s *Session) LastActivity() time.Time {
+	last := s.UpdatedAt
+	if n := len(s.Messages); n > 0 && s.Messages[n-1].Timestamp.After(last) {
+		last = s.Messages[n-1].Timestamp
+	}
+	return last
+}
+
+// AwaitingTool returns true if the last turn is an assistant message with
+// tool calls that have no result yet
+func (s *Session) AwaitingTool() bool {
+	n := len(s.Messages)
+	if n == 0 || s.Messages[n-1].Type != "assistant" {
+		return false
+	}
+	for _, b := range s.Messages[n-1].Blocks {
+		if b.Type == "tool_use" && (b.Call == nil || b.Call.Result == nil) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsActive returns true if the session is being written right now: written
+// within ActiveWindow, or waiting on a tool result for less than
+// ToolWaitWindow
+func (s *Session) IsActive(now time.Time) bool {
+	if s.Deleted {
+		return false
+	}
+	idle := now.Sub(s.LastActivity())
+	return idle < ActiveWindow || (idle < ToolWaitWindow && s.AwaitingTool())
+}
+
+// ActiveSessions counts the project's live sessions and subagents
+func (p *Project) ActiveSessions(now time.Time) (sessions, agents int) {
+	for _, s := range p.Sessions {
+		if !s.IsActive(now) {
+			continue
+		}
+		if s.IsAgent {
+			agents++
+		} else {
+			sessions++
+		}
+	}
+	return sessions, agents
+}
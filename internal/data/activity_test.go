@@ -0,0 +1,41 @@
+package data
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSession_IsActive(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	call := &ToolCall{ID: "t1", Name: "Bash"}
+	waiting := &Message{Type: "assistant", Timestamp: now.Add(-5 * time.Minute), Blocks: []ContentBlock{{Type: "tool_use", Call: call}}}
+	done := &Message{Type: "assistant", Timestamp: now.Add(-5 * time.Minute), Blocks: []ContentBlock{{Type: "text", Text: "done"}}}
+
+	tests := []struct {
+		name    string
+		session *Session
+		want    bool
+	}{
+		{"recently written", &Session{UpdatedAt: now.Add(-10 * time.Second)}, true},
+		{"quiet", &Session{UpdatedAt: now.Add(-5 * time.Minute), Messages: []*Message{done}}, false},
+		{"waiting on a tool", &Session{UpdatedAt: now.Add(-5 * time.Minute), Messages: []*Message{waiting}}, true},
+		{"tool wait expired", &Session{UpdatedAt: now.Add(-time.Hour), Messages: []*Message{{Type: "assistant", Timestamp: now.Add(-time.Hour), Blocks: waiting.Blocks}}}, false},
+		{"entry newer than file time", &Session{UpdatedAt: now.Add(-time.Hour), Messages: []*Message{{Type: "user", Timestamp: now.Add(-time.Second)}}}, true},
+		{"deleted", &Session{UpdatedAt: now, Deleted: true}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.session.IsActive(now); got != tt.want {
+			t.Errorf("%s: IsActive = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	p := &Project{Sessions: []*Session{
+		{UpdatedAt: now},
+		{UpdatedAt: now, IsAgent: true},
+		{UpdatedAt: now, IsAgent: true},
+		{UpdatedAt: now.Add(-time.Hour)},
+	}}
+	if sessions, agents := p.ActiveSessions(now); sessions != 1 || agents != 2 {
+		t.Errorf("ActiveSessions = %d, %d; want 1, 2", sessions, agents)
+	}
+}
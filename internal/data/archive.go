@@ -0,0 +1,249 @@
+package data
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compressed session files are read whole; they are archives and are not
+// expected to grow
+const (
+	gzipExt = ".gz"
+	zstdExt = ".zst"
+)
+
+// TrimSessionExt strips .jsonl and any compression suffix from a file name
+func TrimSessionExt(name string) string {
+	name = strings.TrimSuffix(name, gzipExt)
+	name = strings.TrimSuffix(name, zstdExt)
+	return strings.TrimSuffix(name, ".jsonl")
+}
+
+// IsCompressed returns true for .jsonl.gz and .jsonl.zst files
+func IsCompressed(path string) bool {
+	return strings.HasSuffix(path, ".jsonl"+gzipExt) || strings.HasSuffix(path, ".jsonl"+zstdExt)
+}
+
+// openSessionFile opens a session file for reading from offset, transparently
+// decompressing compressed files. Compressed streams can't seek, so they are
+// always read from the start and the returned offset is 0.
+func openSessionFile(path string, offset int64) (io.ReadCloser, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	switch {
+	case strings.HasSuffix(path, gzipExt):
+		gz, err := gzip.NewReader(bufio.NewReader(file))
+		if err != nil {
+			file.Close()
+			return nil, 0, fmt.Errorf("%s: %w", path, err)
+		}
+		return &stackedReader{Reader: gz, closers: []io.Closer{gz, file}}, 0, nil
+	case strings.HasSuffix(path, zstdExt):
+		return openZstd(file)
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return nil, 0, err
+	}
+	return file, offset, nil
+}
+
+// OpenSessionFile opens a session file for reading from the start,
+// decompressing .jsonl.gz and .jsonl.zst files
+func OpenSessionFile(path string) (io.ReadCloser, error) {
+	r, _, err := openSessionFile(path, 0)
+	return r, err
+}
+
+// openZstd decompresses a .zst file. One decoder goroutine is plenty for
+// reading a transcript line by line.
+func openZstd(file *os.File) (io.ReadCloser, int64, error) {
+	zr, err := zstd.NewReader(bufio.NewReader(file), zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		file.Close()
+		return nil, 0, fmt.Errorf("%s: %w", file.Name(), err)
+	}
+	return &stackedReader{Reader: zr, closers: []io.Closer{closerFunc(func() error {
+		zr.Close()
+		return nil
+	}), file}}, 0, nil
+}
+
+// stackedReader closes a chain of readers innermost first
+type stackedReader struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (r *stackedReader) Close() error {
+	var first error
+	for _, c := range r.closers {
+		if err := c.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+type closerFunc func() error
+
+func (f closerFunc) Close() error { return f() }
+
+// IsArchive returns true for .tar, .tar.gz and .tgz files
+func IsArchive(path string) bool {
+	return strings.HasSuffix(path, ".tar") || strings.HasSuffix(path, ".tar.gz") || strings.HasSuffix(path, ".tgz")
+}
+
+// OpenArchiveRoot extracts a tarball of a projects directory into the user
+// cache and returns the extracted projects directory. The extraction is
+// reused while the archive is unchanged; extractions of earlier contents of
+// the same archive are removed.
+func OpenArchiveRoot(archive string) (string, error) {
+	info, err := os.Stat(archive)
+	if err != nil {
+		return "", err
+	}
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	abs, _ := filepath.Abs(archive)
+	// Named <archive>-<contents>, so older extractions of the archive can be found
+	pathSum := sha256.Sum256([]byte(abs))
+	contentSum := sha256.Sum256([]byte(fmt.Sprintf("%d\x00%d", info.Size(), info.ModTime().UnixNano())))
+	prefix := hex.EncodeToString(pathSum[:8]) + "-"
+	archives := filepath.Join(cache, "claude-mri", "archives")
+	dir := filepath.Join(archives, prefix+hex.EncodeToString(contentSum[:8]))
+
+	if _, err := os.Stat(filepath.Join(dir, ".complete")); err != nil {
+		os.RemoveAll(dir)
+		if err := extractTar(archive, dir); err != nil {
+			os.RemoveAll(dir)
+			return "", fmt.Errorf("%s: %w", archive, err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, ".complete"), nil, 0o644); err != nil {
+			return "", err
+		}
+		pruneExtractions(archives, prefix, dir)
+	}
+	return findProjectsRoot(dir), nil
+}
+
+// pruneExtractions removes the extractions under archives whose names start
+// with prefix, except keep
+func pruneExtractions(archives, prefix, keep string) {
+	old, _ := filepath.Glob(filepath.Join(archives, prefix+"*"))
+	for _, dir := range old {
+		if dir != keep {
+			os.RemoveAll(dir)
+		}
+	}
+}
+
+// extractTar writes the regular files of a tarball under dir, refusing
+// entries that would land outside it
+func extractTar(archive, dir string) error {
+	file, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var r io.Reader = bufio.NewReader(file)
+	if !strings.HasSuffix(archive, ".tar") {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		name := filepath.Clean(filepath.FromSlash(hdr.Name))
+		if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("unsafe path %q in archive", hdr.Name)
+		}
+		target := filepath.Join(dir, name)
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return err
+			}
+			out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o444)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(out, tr)
+			out.Close()
+			if err != nil {
+				return err
+			}
+			os.Chtimes(target, hdr.ModTime, hdr.ModTime)
+		}
+		// Links and devices are skipped; transcripts are plain files
+	}
+}
+
+// findProjectsRoot descends through wrapper directories (projects/,
+// .claude/projects/) to the directory holding project folders: the first
+// one with a subdirectory that contains session files
+func findProjectsRoot(dir string) string {
+	for {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return dir
+		}
+		var subdirs []string
+		for _, e := range entries {
+			if e.IsDir() {
+				sub := filepath.Join(dir, e.Name())
+				if hasSessionFiles(sub) {
+					return dir
+				}
+				subdirs = append(subdirs, sub)
+			}
+		}
+		if len(subdirs) != 1 {
+			return dir
+		}
+		dir = subdirs[0]
+	}
+}
+
+func hasSessionFiles(dir string) bool {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	for _, e := range entries {
+		if !e.IsDir() && isSessionFile(e.Name()) {
+			return true
+		}
+	}
+	return false
+}
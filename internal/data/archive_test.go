@@ -0,0 +1,170 @@
+package data
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const archivedSession = `{"type":"user","uuid":"u1","cwd":"/src/old","message":{"role":"user","content":"archived prompt"}}
+{"type":"assistant","uuid":"a1","message":{"role":"assistant","content":"archived answer"}}
+`
+
+const archivedID = "3f1c2a9e-0000-4000-8000-000000000001"
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write(data)
+	gz.Close()
+	return buf.Bytes()
+}
+
+func TestLoadSession_Gzip(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "-src-old")
+	os.MkdirAll(dir, 0o755)
+	path := filepath.Join(dir, archivedID+".jsonl.gz")
+	os.WriteFile(path, gzipBytes(t, []byte(archivedSession)), 0o644)
+
+	sessions, err := scanSessions(dir, nil)
+	if err != nil || len(sessions) != 1 {
+		t.Fatalf("expected the compressed session found, got %v (%v)", sessions, err)
+	}
+	s := sessions[0]
+	if s.ID != archivedID || s.Prompt != "archived prompt" {
+		t.Errorf("unexpected id/prompt %q %q", s.ID, s.Prompt)
+	}
+	if err := LoadSession(s); err != nil {
+		t.Fatalf("LoadSession: %v", err)
+	}
+	if len(s.Messages) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(s.Messages))
+	}
+
+	// An unchanged archive reads nothing new
+	tail, err := ReadSessionTail(s)
+	if err != nil || tail.Reset || len(tail.Entries) != 0 {
+		t.Errorf("expected an empty tail, got %+v (%v)", tail, err)
+	}
+
+	// A plain copy of the same session wins over the archived one
+	os.WriteFile(filepath.Join(dir, archivedID+".jsonl"), []byte(archivedSession), 0o644)
+	sessions, _ = scanSessions(dir, nil)
+	if len(sessions) != 1 || IsCompressed(sessions[0].FilePath) {
+		t.Errorf("expected only the plain session, got %d", len(sessions))
+	}
+}
+
+func zstdBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw, err := zstd.NewWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw.Write(data)
+	zw.Close()
+	return buf.Bytes()
+}
+
+func TestLoadSession_Zstd(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "-src-old")
+	os.MkdirAll(dir, 0o755)
+	path := filepath.Join(dir, archivedID+".jsonl.zst")
+	os.WriteFile(path, zstdBytes(t, []byte(archivedSession)), 0o644)
+
+	sessions, err := scanSessions(dir, nil)
+	if err != nil || len(sessions) != 1 {
+		t.Fatalf("expected the compressed session found, got %v (%v)", sessions, err)
+	}
+	s := sessions[0]
+	if s.ID != archivedID || s.Prompt != "archived prompt" {
+		t.Errorf("unexpected id/prompt %q %q", s.ID, s.Prompt)
+	}
+	if err := LoadSession(s); err != nil {
+		t.Fatalf("LoadSession: %v", err)
+	}
+	if len(s.Messages) != 2 || s.Messages[1].Blocks[0].Text != "archived answer" {
+		t.Errorf("expected the decompressed session, got %d messages", len(s.Messages))
+	}
+}
+
+func TestScanProjects_TarRoot(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	files := map[string]string{
+		".claude/projects/-src-old/" + archivedID + ".jsonl": archivedSession,
+	}
+	for name, content := range files {
+		tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		tw.Write([]byte(content))
+	}
+	tw.Close()
+
+	archive := filepath.Join(t.TempDir(), "backup.tar.gz")
+	os.WriteFile(archive, gzipBytes(t, buf.Bytes()), 0o644)
+
+	projects, err := ScanProjects(archive)
+	if err != nil {
+		t.Fatalf("ScanProjects: %v", err)
+	}
+	if len(projects) != 1 || projects[0].Cwd != "/src/old" || projects[0].Archive != archive {
+		t.Fatalf("expected one archived project, got %+v", projects)
+	}
+	if len(projects[0].Sessions) != 1 {
+		t.Errorf("expected one session, got %d", len(projects[0].Sessions))
+	}
+
+	// Archives inside a live root are scanned alongside its folders
+	root := t.TempDir()
+	os.Rename(archive, filepath.Join(root, "backup.tar.gz"))
+	projects, err = ScanProjects(root)
+	if err != nil || len(projects) != 1 || projects[0].Archive == "" {
+		t.Errorf("expected the archived project in the live root, got %+v (%v)", projects, err)
+	}
+}
+
+func TestOpenArchiveRoot_PrunesOldExtractions(t *testing.T) {
+	cache := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cache)
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	name := ".claude/projects/-src-old/" + archivedID + ".jsonl"
+	tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(archivedSession)), Typeflag: tar.TypeReg})
+	tw.Write([]byte(archivedSession))
+	tw.Close()
+	archive := filepath.Join(t.TempDir(), "backup.tar")
+	os.WriteFile(archive, buf.Bytes(), 0o644)
+
+	first, err := OpenArchiveRoot(archive)
+	if err != nil {
+		t.Fatalf("OpenArchiveRoot: %v", err)
+	}
+	// The archive changes; its earlier extraction goes
+	later := time.Now().Add(time.Hour)
+	os.Chtimes(archive, later, later)
+	second, err := OpenArchiveRoot(archive)
+	if err != nil {
+		t.Fatalf("OpenArchiveRoot: %v", err)
+	}
+	if first == second {
+		t.Fatal("expected a fresh extraction for the changed archive")
+	}
+	dirs, _ := os.ReadDir(filepath.Join(cache, "claude-mri", "archives"))
+	if len(dirs) != 1 {
+		t.Errorf("expected only the latest extraction kept, got %d", len(dirs))
+	}
+	if _, err := os.Stat(second); err != nil {
+		t.Errorf("latest extraction missing: %v", err)
+	}
+}
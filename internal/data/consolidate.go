@@ -0,0 +1,80 @@
+package data
+
+// Claude Code writes one JSONL line per content block of a streamed API
+// response. All lines of one response share message.id (and requestId);
+// together they form a single model turn.
+
+// APIMessageID returns the id of the API response a message came from,
+// or "" for user messages and lines written without one
+func (m *Message) APIMessageID() string {
+	if m.Type != "assistant" {
+		return ""
+	}
+	return m.Message.ID
+}
+
+// mergeChunk folds a later streamed chunk into the turn it belongs to.
+// Blocks are appended; usage is the same response counted once, so the
+// largest value reported by any chunk is kept.
+func mergeChunk(turn, chunk *Message) {
+	turn.Blocks = append(turn.Blocks, chunk.Blocks...)
+	turn.ChunkUUIDs = append(turn.ChunkUUIDs, chunk.UUID)
+	turn.ChunkUUIDs = append(turn.ChunkUUIDs, chunk.ChunkUUIDs...)
+
+	if chunk.StopReason != "" {
+		turn.StopReason = chunk.StopReason
+	}
+	if turn.Model == "" {
+		turn.Model = chunk.Model
+	}
+	turn.InputTokens = max(turn.InputTokens, chunk.InputTokens)
+	turn.OutputTokens = max(turn.OutputTokens, chunk.OutputTokens)
+	turn.CacheReadTokens = max(turn.CacheReadTokens, chunk.CacheReadTokens)
+	turn.CacheWriteTokens = max(turn.CacheWriteTokens, chunk.CacheWriteTokens)
+
+	// Appending may have moved the blocks; keep tool calls pointing at them
+	for i := range turn.Blocks {
+		b := &turn.Blocks[i]
+		if b.Call == nil {
+			continue
+		}
+		switch b.Type {
+		case "tool_use":
+			b.Call.Use = b
+		case "tool_result":
+			b.Call.Result = b
+		}
+	}
+}
+
+// consolidate merges streamed chunks among newly read entries into their
+// turns, which may have been read earlier. It returns the entries that are
+// new turns or other entries, and the messages among them; chunks merged
+// into earlier turns are dropped. changed lists earlier turns that grew.
+func (s *Session) consolidate(entries []Entry) (kept []Entry, messages []*Message, changed []*Message) {
+	if s.turnsByAPIID == nil {
+		s.turnsByAPIID = make(map[string]*Message)
+	}
+	for _, e := range entries {
+		msg, ok := e.(*Message)
+		if !ok {
+			kept = append(kept, e)
+			continue
+		}
+		id := msg.APIMessageID()
+		if id == "" {
+			kept = append(kept, e)
+			messages = append(messages, msg)
+			continue
+		}
+		if turn, ok := s.turnsByAPIID[id]; ok {
+			mergeChunk(turn, msg)
+			changed = append(changed, turn)
+			continue
+		}
+		s.turnsByAPIID[id] = msg
+		kept = append(kept, e)
+		messages = append(messages, msg)
+	}
+	return kept, messages, changed
+}
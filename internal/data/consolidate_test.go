@@ -0,0 +1,47 @@
+package data
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadSession_MergesStreamedChunks(t *testing.T) {
+	lines := `{"type":"user","uuid":"u1","parentUuid":null,"message":{"role":"user","content":"list files"}}
+{"type":"assistant","uuid":"a1","parentUuid":"u1","requestId":"req_1","message":{"id":"msg_1","role":"assistant","model":"claude-opus-4-5-20251101","content":[{"type":"thinking","thinking":"hmm"}],"usage":{"input_tokens":10,"cache_read_input_tokens":500,"output_tokens":2}}}
+{"type":"assistant","uuid":"a2","parentUuid":"a1","requestId":"req_1","message":{"id":"msg_1","role":"assistant","model":"claude-opus-4-5-20251101","content":[{"type":"tool_use","id":"toolu_1","name":"Bash","input":{"command":"ls"}}],"stop_reason":"tool_use","usage":{"input_tokens":10,"cache_read_input_tokens":500,"output_tokens":40}}}
+{"type":"user","uuid":"u2","parentUuid":"a2","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_1","content":"a.go"}]}}
+`
+	path := filepath.Join(t.TempDir(), "s.jsonl")
+	if err := os.WriteFile(path, []byte(lines), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	session := &Session{ID: "s", FilePath: path}
+	if err := LoadSession(session); err != nil {
+		t.Fatalf("LoadSession: %v", err)
+	}
+	if len(session.Messages) != 3 {
+		t.Fatalf("expected 3 messages after merging chunks, got %d", len(session.Messages))
+	}
+
+	turn := session.Messages[1]
+	if len(turn.Blocks) != 2 {
+		t.Fatalf("expected merged turn to hold 2 blocks, got %d", len(turn.Blocks))
+	}
+	if turn.OutputTokens != 40 || turn.CacheReadTokens != 500 || turn.InputTokens != 10 {
+		t.Errorf("expected usage counted once, got in=%d cache=%d out=%d", turn.InputTokens, turn.CacheReadTokens, turn.OutputTokens)
+	}
+	if turn.StopReason != "tool_use" {
+		t.Errorf("expected stop reason from last chunk, got %q", turn.StopReason)
+	}
+	if len(session.ToolCalls) != 1 || !session.ToolCalls[0].Done() {
+		t.Fatalf("expected tool call in merged turn to be paired")
+	}
+
+	// The tool result's parent is the merged chunk; it must stay on the path
+	path2 := session.Graph().ActivePath()
+	if len(path2) != 3 {
+		t.Errorf("expected all 3 turns on the active path, got %d", len(path2))
+	}
+}
@@ -0,0 +1,183 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// DiagnosticKind classifies a parse diagnostic
+type DiagnosticKind int
+
+const (
+	DiagMalformed      DiagnosticKind = iota // line is not valid JSON or doesn't fit the entry shape
+	DiagUnknownType                          // entry type this version doesn't know
+	DiagUnknownField                         // top-level field this version doesn't know
+	DiagUnknownBlock                         // content block type this version doesn't know
+	DiagUnreadable                           // file or directory could not be read
+	DiagUnknownVersion                       // written by a CLI release newer than any schema adapter
+)
+
+func (k DiagnosticKind) String() string {
+	switch k {
+	case DiagMalformed:
+		return "malformed"
+	case DiagUnknownType:
+		return "unknown type"
+	case DiagUnknownField:
+		return "unknown field"
+	case DiagUnknownBlock:
+		return "unknown block"
+	case DiagUnreadable:
+		return "unreadable"
+	case DiagUnknownVersion:
+		return "unknown schema version"
+	}
+	return "diagnostic"
+}
+
+// Diagnostic records something in a transcript the parser could not
+// handle, so format changes are noticed instead of silently skipped
+type Diagnostic struct {
+	File   string
+	Line   int // first affected line; 0 when not line-specific
+	Kind   DiagnosticKind
+	Detail string // entry type, field or block type not recognised
+	Err    string
+	Count  int // occurrences folded into this diagnostic
+}
+
+func (d Diagnostic) String() string {
+	s := d.Kind.String()
+	if d.Detail != "" {
+		s += " " + d.Detail
+	}
+	if d.Line > 0 {
+		s = fmt.Sprintf("line %d: %s", d.Line, s)
+	}
+	if d.Err != "" {
+		s += ": " + d.Err
+	}
+	if d.Count > 1 {
+		s += fmt.Sprintf(" (×%d)", d.Count)
+	}
+	return s
+}
+
+// maxMalformedDiagnostics caps per-line diagnostics kept for one session;
+// later ones are only counted on the last one kept
+const maxMalformedDiagnostics = 100
+
+// knownFields are the top-level entry fields the parser understands or
+// deliberately ignores
+var knownFields = map[string]bool{
+	"type": true, "uuid": true, "parentUuid": true, "logicalParentUuid": true,
+	"timestamp": true, "sessionId": true, "agentId": true, "isSidechain": true,
+	"message": true, "requestId": true, "cwd": true, "gitBranch": true,
+	"version": true, "userType": true, "slug": true, "isMeta": true,
+	"thinkingMetadata": true, "toolUseResult": true, "todos": true,
+	"isCompactSummary": true, "isVisibleInTranscriptOnly": true,
+	"isApiErrorMessage": true, "imagePasteIds": true, "permissionMode": true,
+	"costUSD": true, "durationMs": true,
+	// system
+	"subtype": true, "content": true, "level": true, "toolUseID": true,
+	"compactMetadata": true, "hookInfos": true, "hookErrors": true,
+	"hookCount": true, "preventedContinuation": true, "stopReason": true,
+	"hasOutput": true, "error": true, "cause": true, "retryInMs": true,
+	"retryAttempt": true, "maxRetries": true,
+	// summary
+	"summary": true, "leafUuid": true,
+	// file-history-snapshot
+	"messageId": true, "snapshot": true, "isSnapshotUpdate": true,
+	// queue-operation
+	"operation": true,
+}
+
+// knownBlockTypes are the content block types the parser renders
+var knownBlockTypes = map[string]bool{
+	"text": true, "thinking": true, "redacted_thinking": true,
+	"tool_use": true, "tool_result": true, "image": true, "document": true,
+}
+
+// lineDiagnostics reports unknown entry types, fields and block types of a
+// successfully parsed line
+func lineDiagnostics(line []byte, entry Entry, file string, lineNo int) []Diagnostic {
+	var diags []Diagnostic
+	if u, ok := entry.(*UnknownEntry); ok {
+		diags = append(diags, Diagnostic{File: file, Line: lineNo, Kind: DiagUnknownType, Detail: u.Type, Count: 1})
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(line, &fields); err == nil {
+		var unknown []string
+		for key := range fields {
+			if !knownFields[key] {
+				unknown = append(unknown, key)
+			}
+		}
+		sort.Strings(unknown)
+		for _, key := range unknown {
+			diags = append(diags, Diagnostic{File: file, Line: lineNo, Kind: DiagUnknownField, Detail: entry.EntryType() + "." + key, Count: 1})
+		}
+	}
+
+	if msg, ok := entry.(*Message); ok {
+		if unknownSchemaVersion(msg.Version) {
+			diags = append(diags, Diagnostic{File: file, Line: lineNo, Kind: DiagUnknownVersion, Detail: msg.Version, Count: 1})
+		}
+		for _, b := range msg.Blocks {
+			if !knownBlockTypes[b.Type] {
+				diags = append(diags, Diagnostic{File: file, Line: lineNo, Kind: DiagUnknownBlock, Detail: b.Type, Count: 1})
+			}
+		}
+	}
+	return diags
+}
+
+// addDiagnostics folds new diagnostics into the session's list. Unknown
+// types, fields and blocks are reported once with a count; malformed lines
+// individually up to a cap.
+func (s *Session) addDiagnostics(diags []Diagnostic) {
+	for _, d := range diags {
+		if d.Kind == DiagMalformed {
+			if s.malformed < maxMalformedDiagnostics {
+				s.Diagnostics = append(s.Diagnostics, d)
+			} else {
+				for i := len(s.Diagnostics) - 1; i >= 0; i-- {
+					if s.Diagnostics[i].Kind == DiagMalformed {
+						s.Diagnostics[i].Count++
+						break
+					}
+				}
+			}
+			s.malformed++
+			continue
+		}
+		merged := false
+		for i := range s.Diagnostics {
+			if s.Diagnostics[i].Kind == d.Kind && s.Diagnostics[i].Detail == d.Detail {
+				s.Diagnostics[i].Count += d.Count
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			s.Diagnostics = append(s.Diagnostics, d)
+		}
+	}
+}
+
+// BadLines returns the number of lines that could not be parsed
+func (s *Session) BadLines() int {
+	return s.malformed
+}
+
+// DiagnosticCount returns the number of diagnostics on the project and its
+// loaded sessions
+func (p *Project) DiagnosticCount() int {
+	n := len(p.Diagnostics)
+	for _, s := range p.Sessions {
+		n += len(s.Diagnostics)
+	}
+	return n
+}
@@ -0,0 +1,250 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Entry is one line of a session file. Messages (user/assistant) and the
+// event entry types below all implement it.
+type Entry interface {
+	EntryType() string // the JSONL "type" field
+	EntryID() string   // uuid, or the best stable identifier the entry has
+	EntryTime() time.Time
+}
+
+// Event is a non-message entry, shown inline as a single row
+type Event interface {
+	Entry
+	Describe() string // one-line description
+	Details() string  // full content for the expanded view
+}
+
+// Known entry types
+const (
+	EntryUser                = "user"
+	EntryAssistant           = "assistant"
+	EntrySummary             = "summary"
+	EntrySystem              = "system"
+	EntryFileHistorySnapshot = "file-history-snapshot"
+	EntryQueueOperation      = "queue-operation"
+)
+
+func (m *Message) EntryType() string    { return m.Type }
+func (m *Message) EntryID() string      { return m.UUID }
+func (m *Message) EntryTime() time.Time { return m.Timestamp }
+
+// SummaryEntry is a conversation summary written after compaction or on resume
+type SummaryEntry struct {
+	Text     string `json:"summary"`
+	LeafUUID string `json:"leafUuid"`
+}
+
+func (e *SummaryEntry) EntryType() string    { return EntrySummary }
+func (e *SummaryEntry) EntryID() string      { return "summary:" + e.LeafUUID }
+func (e *SummaryEntry) EntryTime() time.Time { return time.Time{} }
+func (e *SummaryEntry) Describe() string     { return "summary: " + e.Text }
+func (e *SummaryEntry) Details() string      { return e.Text }
+
+// SystemEntry covers system lines: compaction boundaries, hook output,
+// API errors, local command output and informational notices
+type SystemEntry struct {
+	UUID              string    `json:"uuid"`
+	ParentUUID        *string   `json:"parentUuid"`
+	LogicalParentUUID *string   `json:"logicalParentUuid"` // set on compaction boundaries
+	Timestamp         time.Time `json:"timestamp"`
+	SessionID         string    `json:"sessionId"`
+	Subtype           string    `json:"subtype"` // e.g. "compact_boundary", "stop_hook_summary", "api_error"
+	Content           string    `json:"content"`
+	Level             string    `json:"level"` // "info" | "warning" | "error"
+	ToolUseID         string    `json:"toolUseID"`
+
+	CompactMetadata *struct {
+		Trigger   string `json:"trigger"` // "auto" | "manual"
+		PreTokens int    `json:"preTokens"`
+	} `json:"compactMetadata"`
+
+	HookInfos []struct {
+		Command string `json:"command"`
+	} `json:"hookInfos"`
+	HookErrors []string `json:"hookErrors"`
+
+	Error json.RawMessage `json:"error"`
+}
+
+func (e *SystemEntry) EntryType() string    { return EntrySystem }
+func (e *SystemEntry) EntryID() string      { return e.UUID }
+func (e *SystemEntry) EntryTime() time.Time { return e.Timestamp }
+
+// IsCompaction returns true for compaction boundary markers
+func (e *SystemEntry) IsCompaction() bool {
+	return e.Subtype == "compact_boundary"
+}
+
+// IsError returns true for entries reporting a failure
+func (e *SystemEntry) IsError() bool {
+	return e.Level == "error" || e.Subtype == "api_error" || len(e.HookErrors) > 0
+}
+
+func (e *SystemEntry) Describe() string {
+	switch {
+	case e.IsCompaction():
+		s := "compacted"
+		if md := e.CompactMetadata; md != nil {
+			s += fmt.Sprintf(" (%s, %d tokens before)", md.Trigger, md.PreTokens)
+		}
+		return s
+	case e.Subtype == "stop_hook_summary":
+		s := fmt.Sprintf("stop hooks: %d ran", len(e.HookInfos))
+		if n := len(e.HookErrors); n > 0 {
+			s += fmt.Sprintf(", %d failed", n)
+		}
+		return s
+	case e.Subtype == "api_error":
+		return "API error"
+	}
+	label := "system"
+	if e.Subtype != "" {
+		label = e.Subtype
+	}
+	if e.Content != "" {
+		return label + ": " + firstLine(e.Content)
+	}
+	return label
+}
+
+func (e *SystemEntry) Details() string {
+	var parts []string
+	if e.Content != "" {
+		parts = append(parts, e.Content)
+	}
+	for _, h := range e.HookInfos {
+		parts = append(parts, "hook: "+h.Command)
+	}
+	for _, h := range e.HookErrors {
+		parts = append(parts, "hook error: "+h)
+	}
+	if len(e.Error) > 0 {
+		parts = append(parts, formatJSON(e.Error))
+	}
+	return strings.Join(parts, "\n")
+}
+
+// FileHistorySnapshotEntry records file backups taken before a message's edits
+type FileHistorySnapshotEntry struct {
+	MessageID        string `json:"messageId"`
+	IsSnapshotUpdate bool   `json:"isSnapshotUpdate"`
+	Snapshot         struct {
+		MessageID          string                     `json:"messageId"`
+		Timestamp          time.Time                  `json:"timestamp"`
+		TrackedFileBackups map[string]json.RawMessage `json:"trackedFileBackups"`
+	} `json:"snapshot"`
+}
+
+func (e *FileHistorySnapshotEntry) EntryType() string    { return EntryFileHistorySnapshot }
+func (e *FileHistorySnapshotEntry) EntryID() string      { return "snapshot:" + e.MessageID }
+func (e *FileHistorySnapshotEntry) EntryTime() time.Time { return e.Snapshot.Timestamp }
+
+func (e *FileHistorySnapshotEntry) Describe() string {
+	n := len(e.Snapshot.TrackedFileBackups)
+	if n == 1 {
+		return "file snapshot: 1 file"
+	}
+	return fmt.Sprintf("file snapshot: %d files", n)
+}
+
+func (e *FileHistorySnapshotEntry) Details() string {
+	files := make([]string, 0, len(e.Snapshot.TrackedFileBackups))
+	for path := range e.Snapshot.TrackedFileBackups {
+		files = append(files, path)
+	}
+	sort.Strings(files)
+	return strings.Join(files, "\n")
+}
+
+// QueueOperationEntry records prompts queued while the model was busy
+type QueueOperationEntry struct {
+	Operation string    `json:"operation"` // "enqueue" | "dequeue" | "remove"
+	Timestamp time.Time `json:"timestamp"`
+	SessionID string    `json:"sessionId"`
+	Content   string    `json:"content"`
+}
+
+func (e *QueueOperationEntry) EntryType() string { return EntryQueueOperation }
+func (e *QueueOperationEntry) EntryID() string {
+	return "queue:" + e.Operation + ":" + e.Timestamp.Format(time.RFC3339Nano)
+}
+func (e *QueueOperationEntry) EntryTime() time.Time { return e.Timestamp }
+
+func (e *QueueOperationEntry) Describe() string {
+	if e.Content != "" {
+		return "queue " + e.Operation + ": " + firstLine(e.Content)
+	}
+	return "queue " + e.Operation
+}
+
+func (e *QueueOperationEntry) Details() string { return e.Content }
+
+// UnknownEntry passes through entry types this version doesn't know about
+type UnknownEntry struct {
+	Type      string          `json:"type"`
+	UUID      string          `json:"uuid"`
+	Timestamp time.Time       `json:"timestamp"`
+	Raw       json.RawMessage `json:"-"`
+}
+
+func (e *UnknownEntry) EntryType() string    { return e.Type }
+func (e *UnknownEntry) EntryID() string      { return e.UUID }
+func (e *UnknownEntry) EntryTime() time.Time { return e.Timestamp }
+func (e *UnknownEntry) Describe() string     { return "unknown entry: " + e.Type }
+func (e *UnknownEntry) Details() string      { return formatJSON(e.Raw) }
+
+// ParseEntryLine parses a single JSONL line into a typed Entry
+func ParseEntryLine(line []byte) (Entry, error) {
+	var typeCheck struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(line, &typeCheck); err != nil {
+		return nil, err
+	}
+
+	var entry Entry
+	switch typeCheck.Type {
+	case EntryUser, EntryAssistant:
+		msg, err := ParseMessageLine(line)
+		if err != nil || msg == nil {
+			return nil, err
+		}
+		return msg, nil
+	case EntrySummary:
+		entry = &SummaryEntry{}
+	case EntrySystem:
+		entry = &SystemEntry{}
+	case EntryFileHistorySnapshot:
+		entry = &FileHistorySnapshotEntry{}
+	case EntryQueueOperation:
+		entry = &QueueOperationEntry{}
+	default:
+		unknown := &UnknownEntry{Raw: append(json.RawMessage(nil), line...)}
+		if err := json.Unmarshal(line, unknown); err != nil {
+			return nil, err
+		}
+		return unknown, nil
+	}
+
+	if err := json.Unmarshal(line, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func firstLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i] + " …"
+	}
+	return s
+}
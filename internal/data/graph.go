@@ -0,0 +1,212 @@
+package data
+
+import "sort"
+
+// GraphNode is an entry placed in the conversation graph
+type GraphNode struct {
+	UUID     string
+	Entry    Entry
+	Parent   *GraphNode
+	Children []*GraphNode
+	order    int // position in the session file
+}
+
+// IsFork returns true if the conversation branches at this node
+func (n *GraphNode) IsFork() bool {
+	return len(n.Children) > 1
+}
+
+// Branch is one root-to-leaf path through the conversation graph
+type Branch struct {
+	Leaf   *GraphNode
+	Active bool       // the path the session continued on (most recently written leaf)
+	Fork   *GraphNode // last node shared with the active branch; nil for the active branch
+}
+
+// Graph is the parent/child structure of a session, rebuilt from parentUuid
+// links. Rewinding or editing a prompt leaves the abandoned continuation in
+// the file as a separate branch.
+type Graph struct {
+	Nodes map[string]*GraphNode
+	Roots []*GraphNode
+
+	entries  []Entry
+	anchors  []string // per entry: UUID of the closest preceding graph node
+	branches []*Branch
+}
+
+// graphParent returns the uuid and parent uuid linking an entry into the
+// graph. Entries without a uuid (summaries, snapshots) and sidechain
+// messages are kept out of the graph.
+func graphParent(e Entry) (uuid, parent string, ok bool) {
+	switch e := e.(type) {
+	case *Message:
+		if e.UUID == "" || e.IsSidechain {
+			return "", "", false
+		}
+		if e.ParentUUID != nil {
+			parent = *e.ParentUUID
+		}
+		return e.UUID, parent, true
+	case *SystemEntry:
+		if e.UUID == "" {
+			return "", "", false
+		}
+		if e.ParentUUID != nil {
+			parent = *e.ParentUUID
+		} else if e.LogicalParentUUID != nil {
+			// Compaction restarts the chain; keep it connected
+			parent = *e.LogicalParentUUID
+		}
+		return e.UUID, parent, true
+	}
+	return "", "", false
+}
+
+// BuildGraph links entries by parentUuid
+func BuildGraph(entries []Entry) *Graph {
+	g := &Graph{
+		Nodes:   make(map[string]*GraphNode),
+		entries: entries,
+		anchors: make([]string, len(entries)),
+	}
+
+	parents := make(map[*GraphNode]string)
+	anchor := ""
+	for i, e := range entries {
+		g.anchors[i] = anchor
+		uuid, parent, ok := graphParent(e)
+		if !ok || g.Nodes[uuid] != nil {
+			continue
+		}
+		node := &GraphNode{UUID: uuid, Entry: e, order: i}
+		g.Nodes[uuid] = node
+		parents[node] = parent
+		anchor = uuid
+		// Merged streaming chunks resolve to the turn they were folded into
+		if msg, ok := e.(*Message); ok {
+			for _, chunk := range msg.ChunkUUIDs {
+				if g.Nodes[chunk] == nil {
+					g.Nodes[chunk] = node
+				}
+			}
+		}
+	}
+
+	// Link in file order so children keep their write order
+	ordered := make([]*GraphNode, 0, len(parents))
+	for n := range parents {
+		ordered = append(ordered, n)
+	}
+	sort.Slice(ordered, func(i, j int) bool { return ordered[i].order < ordered[j].order })
+	for _, n := range ordered {
+		if p, ok := g.Nodes[parents[n]]; ok && p != n && p.order < n.order {
+			n.Parent = p
+			p.Children = append(p.Children, n)
+		} else {
+			// Parent missing from this file (e.g. resumed session): new root
+			g.Roots = append(g.Roots, n)
+		}
+	}
+
+	g.buildBranches(ordered)
+	return g
+}
+
+func (g *Graph) buildBranches(ordered []*GraphNode) {
+	var leaves []*GraphNode
+	for _, n := range ordered {
+		if len(n.Children) == 0 {
+			leaves = append(leaves, n)
+		}
+	}
+	if len(leaves) == 0 {
+		return
+	}
+	// Newest leaf first; it is the active branch
+	sort.Slice(leaves, func(i, j int) bool { return leaves[i].order > leaves[j].order })
+
+	active := make(map[*GraphNode]bool)
+	for n := leaves[0]; n != nil; n = n.Parent {
+		active[n] = true
+	}
+
+	g.branches = append(g.branches, &Branch{Leaf: leaves[0], Active: true})
+	for _, leaf := range leaves[1:] {
+		b := &Branch{Leaf: leaf}
+		for n := leaf; n != nil; n = n.Parent {
+			if active[n] {
+				b.Fork = n
+				break
+			}
+		}
+		g.branches = append(g.branches, b)
+	}
+}
+
+// Branches returns every branch, the active one first and then
+// abandoned branches newest first
+func (g *Graph) Branches() []*Branch {
+	return g.branches
+}
+
+// Forks returns the nodes at which the conversation branches, in file order
+func (g *Graph) Forks() []*GraphNode {
+	var forks []*GraphNode
+	for uuid, n := range g.Nodes {
+		if n.IsFork() && n.UUID == uuid {
+			forks = append(forks, n)
+		}
+	}
+	sort.Slice(forks, func(i, j int) bool { return forks[i].order < forks[j].order })
+	return forks
+}
+
+// Path returns the entries along a branch in file order. Entries outside the
+// graph (summaries, snapshots, sidechains) are kept when the graph entry
+// written just before them is on the path. A nil branch returns all entries.
+func (g *Graph) Path(b *Branch) []Entry {
+	if b == nil {
+		return g.entries
+	}
+	onPath := make(map[string]bool)
+	for n := b.Leaf; n != nil; n = n.Parent {
+		onPath[n.UUID] = true
+		if msg, ok := n.Entry.(*Message); ok {
+			for _, chunk := range msg.ChunkUUIDs {
+				onPath[chunk] = true
+			}
+		}
+	}
+
+	var path []Entry
+	for i, e := range g.entries {
+		if uuid, _, ok := graphParent(e); ok && g.Nodes[uuid] != nil && g.Nodes[uuid].Entry == e {
+			if onPath[uuid] {
+				path = append(path, e)
+			}
+			continue
+		}
+		if g.anchors[i] == "" || onPath[g.anchors[i]] {
+			path = append(path, e)
+		}
+	}
+	return path
+}
+
+// ActivePath returns the entries of the active branch, or all entries if
+// the session has no linked entries
+func (g *Graph) ActivePath() []Entry {
+	if len(g.branches) == 0 {
+		return g.entries
+	}
+	return g.Path(g.branches[0])
+}
+
+// Graph returns the session's conversation graph, building it on first use
+func (s *Session) Graph() *Graph {
+	if s.graph == nil {
+		s.graph = BuildGraph(s.Entries)
+	}
+	return s.graph
+}
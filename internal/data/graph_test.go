@@ -0,0 +1,65 @@
+package data
+
+import "testing"
+
+func graphTestEntries(t *testing.T, lines ...string) []Entry {
+	t.Helper()
+	var entries []Entry
+	for _, line := range lines {
+		e, err := ParseEntryLine([]byte(line))
+		if err != nil {
+			t.Fatalf("parse %s: %v", line, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func TestBuildGraph_DetectsRewoundBranch(t *testing.T) {
+	entries := graphTestEntries(t,
+		`{"type":"user","uuid":"u1","parentUuid":null,"message":{"role":"user","content":"start"}}`,
+		`{"type":"assistant","uuid":"a1","parentUuid":"u1","message":{"role":"assistant","content":"ok"}}`,
+		`{"type":"user","uuid":"u2","parentUuid":"a1","message":{"role":"user","content":"first try"}}`,
+		`{"type":"assistant","uuid":"a2","parentUuid":"u2","message":{"role":"assistant","content":"abandoned"}}`,
+		`{"type":"file-history-snapshot","messageId":"u2","snapshot":{"messageId":"u2"}}`,
+		// User rewound to a1 and edited the prompt
+		`{"type":"user","uuid":"u3","parentUuid":"a1","message":{"role":"user","content":"second try"}}`,
+		`{"type":"assistant","uuid":"a3","parentUuid":"u3","message":{"role":"assistant","content":"kept"}}`,
+	)
+
+	g := BuildGraph(entries)
+	forks := g.Forks()
+	if len(forks) != 1 || forks[0].UUID != "a1" {
+		t.Fatalf("expected a single fork at a1, got %d forks", len(forks))
+	}
+
+	branches := g.Branches()
+	if len(branches) != 2 {
+		t.Fatalf("expected 2 branches, got %d", len(branches))
+	}
+	if !branches[0].Active || branches[0].Leaf.UUID != "a3" {
+		t.Errorf("expected active branch ending at a3, got %s", branches[0].Leaf.UUID)
+	}
+	if branches[1].Fork == nil || branches[1].Fork.UUID != "a1" {
+		t.Errorf("expected abandoned branch to fork at a1")
+	}
+
+	var ids []string
+	for _, e := range g.ActivePath() {
+		ids = append(ids, e.EntryID())
+	}
+	if got, want := len(ids), 4; got != want {
+		t.Fatalf("expected %d entries on active path, got %v", want, ids)
+	}
+	for i, want := range []string{"u1", "a1", "u3", "a3"} {
+		if ids[i] != want {
+			t.Errorf("active path[%d]: expected %s, got %s", i, want, ids[i])
+		}
+	}
+
+	// The snapshot follows a2, so it belongs to the abandoned branch
+	abandoned := g.Path(branches[1])
+	if len(abandoned) != 5 || abandoned[4].EntryType() != EntryFileHistorySnapshot {
+		t.Errorf("expected abandoned path to end with its snapshot, got %d entries", len(abandoned))
+	}
+}
@@ -0,0 +1,135 @@
+package data
+
+import (
+	"bufio"
+	"strings"
+)
+
+// peekSession reads the first lines of a session file for the spawning
+// session, first prompt and environment, without loading the whole file
+func peekSession(session *Session) {
+	file, _, err := openSessionFile(session.FilePath, 0)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	for i := 0; i < 10; i++ {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			if msg, perr := ParseMessageLine(line); perr == nil && msg != nil {
+				session.noteEnvironment(msg)
+				if session.IsAgent && session.ParentSessionID == "" {
+					session.ParentSessionID = msg.SessionID
+				}
+				if session.Prompt == "" && msg.Type == "user" {
+					session.Prompt = messageText(msg)
+				}
+				if session.Cwd != "" && session.Prompt != "" {
+					return
+				}
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// noteEnvironment records the cwd, branch and CLI version of a message;
+// later messages win, since the branch can change during a session
+func (s *Session) noteEnvironment(msg *Message) {
+	if msg.Cwd != "" {
+		s.Cwd = msg.Cwd
+	}
+	if msg.GitBranch != "" {
+		s.GitBranch = msg.GitBranch
+	}
+	if msg.Version != "" {
+		s.Version = msg.Version
+	}
+}
+
+// projectCwd returns the working directory recorded by the project's
+// most recently updated session that has one
+func projectCwd(p *Project) string {
+	var cwd string
+	var newest *Session
+	for _, s := range p.Sessions {
+		if s.Cwd == "" || s.IsAgent {
+			continue
+		}
+		if newest == nil || s.UpdatedAt.After(newest.UpdatedAt) {
+			newest = s
+			cwd = s.Cwd
+		}
+	}
+	return cwd
+}
+
+// pathComponents splits a recorded cwd (Unix or Windows style) into its
+// components, or falls back to the dash-separated folder name
+func pathComponents(p *Project) []string {
+	var parts []string
+	if p.Cwd != "" {
+		parts = strings.FieldsFunc(p.Cwd, func(r rune) bool { return r == '/' || r == '\\' })
+	} else {
+		parts = strings.FieldsFunc(p.folderName(), func(r rune) bool { return r == '-' })
+	}
+	return parts
+}
+
+func (p *Project) folderName() string {
+	name := p.Path
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+// assignDisplayNames names each project after the last component of its
+// path, adding parent components until names are unique
+func assignDisplayNames(projects []*Project) {
+	components := make([][]string, len(projects))
+	depth := make([]int, len(projects))
+	for i, p := range projects {
+		components[i] = pathComponents(p)
+		depth[i] = 1
+	}
+
+	name := func(i int) string {
+		parts := components[i]
+		if len(parts) == 0 {
+			return projects[i].folderName()
+		}
+		n := min(depth[i], len(parts))
+		return strings.Join(parts[len(parts)-n:], "/")
+	}
+
+	for {
+		byName := make(map[string][]int)
+		for i := range projects {
+			byName[name(i)] = append(byName[name(i)], i)
+		}
+		grew := false
+		for _, idxs := range byName {
+			if len(idxs) < 2 {
+				continue
+			}
+			for _, i := range idxs {
+				if depth[i] < len(components[i]) {
+					depth[i]++
+					grew = true
+				}
+			}
+		}
+		if !grew {
+			break
+		}
+	}
+
+	for i, p := range projects {
+		p.Name = name(i)
+	}
+}
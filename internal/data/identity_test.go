@@ -0,0 +1,28 @@
+package data
+
+import "testing"
+
+func TestAssignDisplayNames_DisambiguatesByCwd(t *testing.T) {
+	projects := []*Project{
+		{Path: "/p/-home-nat-work-api", Cwd: "/home/nat/work/api"},
+		{Path: "/p/-home-nat-personal-api", Cwd: "/home/nat/personal/api"},
+		{Path: "/p/-home-nat-my-cool-app", Cwd: "/home/nat/my-cool-app"},
+		{Path: `C:\p\C--Users-Nat-source-beads`, Cwd: `C:\Users\Nat\source\beads`},
+	}
+	assignDisplayNames(projects)
+
+	want := []string{"work/api", "personal/api", "my-cool-app", "beads"}
+	for i, p := range projects {
+		if p.Name != want[i] {
+			t.Errorf("project %d: expected name %q, got %q", i, want[i], p.Name)
+		}
+	}
+}
+
+func TestAssignDisplayNames_FallsBackToFolderName(t *testing.T) {
+	projects := []*Project{{Path: "/p/C--Users-Nat-source-beads"}}
+	assignDisplayNames(projects)
+	if projects[0].Name != "beads" {
+		t.Errorf("expected fallback name 'beads', got %q", projects[0].Name)
+	}
+}
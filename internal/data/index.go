@@ -0,0 +1,318 @@
+package data
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// indexVersion is bumped whenever SessionSummary changes shape, which
+// discards older caches
+const indexVersion = 3
+
+// SessionSummary is per-session metadata kept in the on-disk index, so the
+// tree can be built and sorted without opening every session file
+type SessionSummary struct {
+	FirstPrompt       string    `json:"firstPrompt"`
+	UserMessages      int       `json:"userMessages"`
+	AssistantMessages int       `json:"assistantMessages"` // one per API response
+	InputTokens       int       `json:"inputTokens"`
+	OutputTokens      int       `json:"outputTokens"`
+	CacheReadTokens   int       `json:"cacheReadTokens"`
+	CacheWriteTokens  int       `json:"cacheWriteTokens"`
+	FirstTimestamp    time.Time `json:"firstTimestamp"`
+	LastTimestamp     time.Time `json:"lastTimestamp"`
+	Models            []string  `json:"models"`
+	Errors            int       `json:"errors"`
+
+	Usage []UsageBucket `json:"usage"` // hourly token usage per model
+
+	Cwd             string `json:"cwd"`
+	GitBranch       string `json:"gitBranch"`
+	Version         string `json:"version"`
+	ParentSessionID string `json:"parentSessionId"`
+
+	// State for continuing the summary when the file grows
+	Offset    int64  `json:"offset"`
+	LastAPIID string `json:"lastApiId"`
+	LastUsage [4]int `json:"lastUsage"` // usage already counted for LastAPIID
+}
+
+// Messages returns the number of user and assistant messages
+func (s *SessionSummary) Messages() int {
+	return s.UserMessages + s.AssistantMessages
+}
+
+// add folds one message into the summary
+func (s *SessionSummary) add(msg *Message) {
+	if !msg.Timestamp.IsZero() {
+		if s.FirstTimestamp.IsZero() || msg.Timestamp.Before(s.FirstTimestamp) {
+			s.FirstTimestamp = msg.Timestamp
+		}
+		if msg.Timestamp.After(s.LastTimestamp) {
+			s.LastTimestamp = msg.Timestamp
+		}
+	}
+	if msg.Cwd != "" {
+		s.Cwd = msg.Cwd
+	}
+	if msg.GitBranch != "" {
+		s.GitBranch = msg.GitBranch
+	}
+	if msg.Version != "" {
+		s.Version = msg.Version
+	}
+	if s.ParentSessionID == "" {
+		s.ParentSessionID = msg.SessionID
+	}
+	for _, b := range msg.Blocks {
+		if b.Type == "tool_result" && b.IsError {
+			s.Errors++
+		}
+	}
+
+	if msg.Type == "user" {
+		s.UserMessages++
+		if s.FirstPrompt == "" {
+			s.FirstPrompt = messageText(msg)
+		}
+		return
+	}
+
+	if msg.Model != "" && !containsString(s.Models, msg.Model) {
+		s.Models = append(s.Models, msg.Model)
+		sort.Strings(s.Models)
+	}
+
+	// Streamed chunks of one response: count the message and usage once,
+	// keeping the largest value seen (see mergeChunk)
+	usage := [4]int{msg.InputTokens, msg.OutputTokens, msg.CacheReadTokens, msg.CacheWriteTokens}
+	var costUSD float64
+	if id := msg.APIMessageID(); id == "" || id != s.LastAPIID {
+		s.AssistantMessages++
+		s.LastAPIID = id
+		s.LastUsage = [4]int{}
+		// A merged turn keeps the cost recorded on its first chunk
+		costUSD = msg.CostUSD
+	}
+	var delta [4]int
+	for i := range usage {
+		if usage[i] > s.LastUsage[i] {
+			delta[i] = usage[i] - s.LastUsage[i]
+			s.LastUsage[i] = usage[i]
+		}
+	}
+	s.InputTokens += delta[0]
+	s.OutputTokens += delta[1]
+	s.CacheReadTokens += delta[2]
+	s.CacheWriteTokens += delta[3]
+	if delta != [4]int{} || costUSD > 0 {
+		s.Usage = addUsage(s.Usage, msg.Timestamp, msg.Model, TokenUsage{
+			Input:      delta[0],
+			Output:     delta[1],
+			CacheRead:  delta[2],
+			CacheWrite: delta[3],
+		}, costUSD)
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// summarizeFrom continues a summary from its offset to the end of the file
+func summarizeFrom(path string, summary *SessionSummary) error {
+	file, offset, err := openSessionFile(path, summary.Offset)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// Compressed files are always summarised from the start
+	if offset != summary.Offset {
+		*summary = SessionSummary{}
+	}
+	reader := bufio.NewReaderSize(file, 64*1024)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			if line[len(line)-1] != '\n' && !json.Valid(bytes.TrimSpace(line)) {
+				return nil // still being written
+			}
+			summary.Offset += int64(len(line))
+			if msg, perr := ParseMessageLine(line); perr == nil && msg != nil {
+				summary.add(msg)
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+type indexEntry struct {
+	Size    int64          `json:"size"`
+	ModTime time.Time      `json:"modTime"`
+	Summary SessionSummary `json:"summary"`
+}
+
+// Index caches session summaries on disk, keyed by file path and
+// validated by size and modification time
+type Index struct {
+	path string
+
+	// scanMu serialises scans: each marks the files it sees and Save drops
+	// the rest, so an overlapping scan would prune what the other recorded
+	scanMu sync.Mutex
+
+	mu      sync.Mutex
+	entries map[string]*indexEntry
+	seen    map[string]bool
+	dirty   bool
+}
+
+type indexFile struct {
+	Version  int                    `json:"version"`
+	Sessions map[string]*indexEntry `json:"sessions"`
+}
+
+// DefaultIndexPath returns the index location under the user cache
+// directory ($XDG_CACHE_HOME/claude-mri on Linux)
+func DefaultIndexPath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "claude-mri", "index.json")
+}
+
+// OpenIndex loads the index at path. A missing or outdated index file
+// yields an empty index.
+func OpenIndex(path string) (*Index, error) {
+	ix := &Index{path: path, entries: make(map[string]*indexEntry)}
+	raw, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return ix, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var f indexFile
+	if err := json.Unmarshal(raw, &f); err != nil || f.Version != indexVersion {
+		return ix, nil // rebuild a corrupt or outdated cache
+	}
+	if f.Sessions != nil {
+		ix.entries = f.Sessions
+	}
+	return ix, nil
+}
+
+// Summary returns the summary for a session file, reusing the cached one
+// if the file is unchanged and continuing it if the file only grew
+func (ix *Index) Summary(path string, info os.FileInfo) (*SessionSummary, error) {
+	ix.mu.Lock()
+	entry := ix.entries[path]
+	if ix.seen == nil {
+		ix.seen = make(map[string]bool)
+	}
+	ix.seen[path] = true
+	ix.mu.Unlock()
+
+	if entry != nil && entry.Size == info.Size() && entry.ModTime.Equal(info.ModTime()) {
+		summary := entry.Summary
+		return &summary, nil
+	}
+
+	var summary SessionSummary
+	if entry != nil && info.Size() > entry.Size && entry.Summary.Offset <= info.Size() {
+		summary = entry.Summary // appended to: continue where we stopped
+		summary.Models = append([]string(nil), entry.Summary.Models...)
+		summary.Usage = append([]UsageBucket(nil), entry.Summary.Usage...)
+	}
+	if err := summarizeFrom(path, &summary); err != nil {
+		return nil, err
+	}
+
+	ix.mu.Lock()
+	ix.entries[path] = &indexEntry{Size: info.Size(), ModTime: info.ModTime(), Summary: summary}
+	ix.dirty = true
+	ix.mu.Unlock()
+	return &summary, nil
+}
+
+// Scan runs scan, which looks sessions up in the index, then saves it.
+// Scans run one at a time. A nil index just runs scan.
+func (ix *Index) Scan(scan func()) error {
+	if ix == nil {
+		scan()
+		return nil
+	}
+	ix.scanMu.Lock()
+	defer ix.scanMu.Unlock()
+	scan()
+	return ix.Save()
+}
+
+// Save writes the index if it changed, dropping files not seen since the
+// last save
+func (ix *Index) Save() error {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	if ix.seen != nil {
+		for path := range ix.entries {
+			if !ix.seen[path] {
+				delete(ix.entries, path)
+				ix.dirty = true
+			}
+		}
+		ix.seen = nil
+	}
+	if !ix.dirty || ix.path == "" {
+		return nil
+	}
+
+	raw, err := json.Marshal(indexFile{Version: indexVersion, Sessions: ix.entries})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(ix.path), 0o755); err != nil {
+		return err
+	}
+	// Write atomically so a crash never leaves a truncated cache
+	tmp := ix.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, ix.path); err != nil {
+		return err
+	}
+	ix.dirty = false
+	return nil
+}
+
+// applySummary fills session metadata from an index summary
+func (s *Session) applySummary(summary *SessionSummary) {
+	s.Summary = summary
+	s.Cwd = summary.Cwd
+	s.GitBranch = summary.GitBranch
+	s.Version = summary.Version
+	s.Prompt = summary.FirstPrompt
+	if s.IsAgent && s.ParentSessionID == "" {
+		s.ParentSessionID = summary.ParentSessionID
+	}
+}
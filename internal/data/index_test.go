@@ -0,0 +1,97 @@
+package data
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestIndex_CachesAndContinuesSummaries(t *testing.T) {
+	dir := t.TempDir()
+	session := filepath.Join(dir, "s.jsonl")
+	first := `{"type":"user","uuid":"u1","cwd":"/src/app","message":{"role":"user","content":"fix the build"}}
+{"type":"assistant","uuid":"a1","message":{"id":"msg_1","role":"assistant","model":"claude-sonnet-4-20250514","content":"on it","usage":{"input_tokens":10,"output_tokens":5}}}
+{"type":"assistant","uuid":"a2","message":{"id":"msg_1","role":"assistant","model":"claude-sonnet-4-20250514","content":"done","usage":{"input_tokens":10,"output_tokens":8}}}
+`
+	if err := os.WriteFile(session, []byte(first), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	indexPath := filepath.Join(dir, "cache", "index.json")
+	ix, err := OpenIndex(indexPath)
+	if err != nil {
+		t.Fatalf("OpenIndex: %v", err)
+	}
+	info, _ := os.Stat(session)
+	sum, err := ix.Summary(session, info)
+	if err != nil {
+		t.Fatalf("Summary: %v", err)
+	}
+	if sum.FirstPrompt != "fix the build" || sum.Cwd != "/src/app" {
+		t.Errorf("unexpected prompt/cwd: %q %q", sum.FirstPrompt, sum.Cwd)
+	}
+	if sum.AssistantMessages != 1 || sum.InputTokens != 10 || sum.OutputTokens != 8 {
+		t.Errorf("expected one turn with usage counted once, got %d turns, %d in, %d out", sum.AssistantMessages, sum.InputTokens, sum.OutputTokens)
+	}
+	if err := ix.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	// Append and reopen: the summary continues from the cached offset
+	f, _ := os.OpenFile(session, os.O_APPEND|os.O_WRONLY, 0)
+	f.WriteString(`{"type":"user","uuid":"u2","message":{"role":"user","content":"thanks"}}` + "\n")
+	f.Close()
+
+	ix, err = OpenIndex(indexPath)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	info, _ = os.Stat(session)
+	sum, err = ix.Summary(session, info)
+	if err != nil {
+		t.Fatalf("Summary: %v", err)
+	}
+	if sum.UserMessages != 2 || sum.AssistantMessages != 1 || sum.FirstPrompt != "fix the build" {
+		t.Errorf("expected continued summary, got %d user / %d assistant, prompt %q", sum.UserMessages, sum.AssistantMessages, sum.FirstPrompt)
+	}
+}
+
+func TestIndex_ScansDoNotOverlap(t *testing.T) {
+	dir := t.TempDir()
+	session := filepath.Join(dir, "s.jsonl")
+	os.WriteFile(session, []byte(`{"type":"user","uuid":"u1","message":{"role":"user","content":"hi"}}`+"\n"), 0o644)
+	info, _ := os.Stat(session)
+
+	ix, _ := OpenIndex(filepath.Join(dir, "index.json"))
+	var mu sync.Mutex
+	var order []string
+	note := func(s string) {
+		mu.Lock()
+		order = append(order, s)
+		mu.Unlock()
+	}
+	done := make(chan struct{})
+	ix.Scan(func() {
+		// A second scan starting now waits for this one and its save
+		go func() {
+			ix.Scan(func() {
+				note("second")
+				ix.Summary(session, info)
+			})
+			close(done)
+		}()
+		time.Sleep(50 * time.Millisecond)
+		ix.Summary(session, info)
+		note("first")
+	})
+	<-done
+
+	if len(order) != 2 || order[0] != "first" || order[1] != "second" {
+		t.Errorf("expected the scans one after the other, got %v", order)
+	}
+	if ix.entries[session] == nil {
+		t.Error("expected the session kept in the index")
+	}
+}
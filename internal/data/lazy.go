@@ -0,0 +1,107 @@
+package data
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// LazyPayloadThreshold is the block payload size (in bytes) above which
+// content is not kept in memory; only a preview is, and the full payload is
+// reread from the session file on demand. Zero disables lazy loading.
+var LazyPayloadThreshold = 1 << 20
+
+// lazyPreviewSize is how much of a deferred payload is kept as a preview
+const lazyPreviewSize = 4 * 1024
+
+// LazyPayload locates the full content of a block whose payload was
+// deferred
+type LazyPayload struct {
+	FilePath string
+	Offset   int64 // start of the JSONL line
+	Length   int   // length of the line in bytes
+	Index    int   // block index within the line
+	Size     int   // size of the full payload in bytes
+	Lines    int   // number of lines in the full payload
+}
+
+// payload returns a pointer to the block's main content field
+func (b *ContentBlock) payload() *string {
+	switch b.Type {
+	case "text":
+		return &b.Text
+	case "thinking":
+		return &b.Thinking
+	case "tool_use":
+		return &b.ToolInput
+	case "tool_result":
+		return &b.Result
+	}
+	return nil
+}
+
+// deferLargePayloads replaces oversized block payloads with a preview
+func deferLargePayloads(msg *Message, path string, offset int64, length int) {
+	if LazyPayloadThreshold <= 0 || length <= LazyPayloadThreshold {
+		return
+	}
+	for i := range msg.Blocks {
+		b := &msg.Blocks[i]
+		field := b.payload()
+		if field == nil || len(*field) <= LazyPayloadThreshold {
+			continue
+		}
+		full := *field
+		b.Lazy = &LazyPayload{
+			FilePath: path,
+			Offset:   offset,
+			Length:   length,
+			Index:    i,
+			Size:     len(full),
+			Lines:    strings.Count(full, "\n") + 1,
+		}
+		*field = previewOf(full)
+	}
+}
+
+// previewOf returns the start of a payload, at most lazyPreviewSize bytes
+// and cut on a rune boundary. The payload may be shorter than the preview
+// when LazyPayloadThreshold is set below it.
+func previewOf(full string) string {
+	n := min(len(full), lazyPreviewSize)
+	for n > 0 && n < len(full) && !utf8.RuneStart(full[n]) {
+		n--
+	}
+	return full[:n]
+}
+
+// LoadPayload rereads a deferred payload from the session file. It is a
+// no-op for blocks that hold their full content.
+func (b *ContentBlock) LoadPayload() error {
+	lazy := b.Lazy
+	if lazy == nil {
+		return nil
+	}
+	file, err := os.Open(lazy.FilePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	line := make([]byte, lazy.Length)
+	if _, err := file.ReadAt(line, lazy.Offset); err != nil && err != io.EOF {
+		return err
+	}
+	msg, err := ParseMessageLine(line)
+	if err != nil {
+		return err
+	}
+	if msg == nil || lazy.Index >= len(msg.Blocks) || msg.Blocks[lazy.Index].Type != b.Type {
+		return fmt.Errorf("payload moved: %s no longer matches at offset %d", lazy.FilePath, lazy.Offset)
+	}
+	*b.payload() = *msg.Blocks[lazy.Index].payload()
+	b.Lazy = nil
+	return nil
+}
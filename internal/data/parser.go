@@ -35,32 +35,23 @@ func ParseMessageLine(line []byte) (*Message, error) {
 	return &msg, nil
 }
 
-// parseMessageMetadata extracts model, usage, stop_reason, and thinking level
+// parseMessageMetadata extracts model, usage, stop_reason and the spawned
+// agent, then lets the schema adapter for the entry's CLI version fill in
+// version-dependent fields
 func parseMessageMetadata(line []byte, msg *Message) {
-	// Parse the full structure to get nested fields
-	var raw struct {
-		ThinkingMetadata *struct {
-			Level string `json:"level"`
-		} `json:"thinkingMetadata"`
-		Message *struct {
-			Model      string `json:"model"`
-			StopReason string `json:"stop_reason"`
-			Usage      *struct {
-				InputTokens             int `json:"input_tokens"`
-				OutputTokens            int `json:"output_tokens"`
-				CacheReadInputTokens    int `json:"cache_read_input_tokens"`
-				CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
-			} `json:"usage"`
-		} `json:"message"`
-	}
-
+	var raw entryFields
 	if err := json.Unmarshal(line, &raw); err != nil {
 		return
 	}
 
-	// Extract thinking level (from user messages)
-	if raw.ThinkingMetadata != nil {
-		msg.ThinkingLevel = raw.ThinkingMetadata.Level
+	// Extract the spawned agent from Task results
+	if len(raw.ToolUseResult) > 0 {
+		var result struct {
+			AgentID string `json:"agentId"`
+		}
+		if json.Unmarshal(raw.ToolUseResult, &result) == nil {
+			msg.ResultAgentID = result.AgentID
+		}
 	}
 
 	// Extract model, stop_reason, and usage (from assistant messages)
@@ -75,6 +66,8 @@ func parseMessageMetadata(line []byte, msg *Message) {
 			msg.CacheWriteTokens = raw.Message.Usage.CacheCreationInputTokens
 		}
 	}
+
+	adaptEntry(msg, &raw)
 }
 
 // parseContentBlocks handles both string and array content
@@ -91,13 +84,15 @@ func parseContentBlocks(raw json.RawMessage) []ContentBlock {
 
 	// Try as array
 	var blocks []struct {
-		Type     string          `json:"type"`
-		Text     string          `json:"text,omitempty"`
-		Thinking string          `json:"thinking,omitempty"`
-		Name     string          `json:"name,omitempty"`
-		ID       string          `json:"id,omitempty"`
-		Input    json.RawMessage `json:"input,omitempty"`
-		Content  json.RawMessage `json:"content,omitempty"`
+		Type      string          `json:"type"`
+		Text      string          `json:"text,omitempty"`
+		Thinking  string          `json:"thinking,omitempty"`
+		Name      string          `json:"name,omitempty"`
+		ID        string          `json:"id,omitempty"`
+		ToolUseID string          `json:"tool_use_id,omitempty"`
+		IsError   bool            `json:"is_error,omitempty"`
+		Input     json.RawMessage `json:"input,omitempty"`
+		Content   json.RawMessage `json:"content,omitempty"`
 	}
 	if err := json.Unmarshal(raw, &blocks); err != nil {
 		return nil
@@ -118,9 +113,10 @@ func parseContentBlocks(raw json.RawMessage) []ContentBlock {
 				block.ToolInput = formatJSON(b.Input)
 			}
 		case "tool_result":
-			block.ToolID = b.ID
+			block.ToolID = b.ToolUseID
+			block.IsError = b.IsError
 			if len(b.Content) > 0 {
-				block.Result = formatJSON(b.Content)
+				block.Result = formatToolResult(b.Content)
 			}
 		}
 		result = append(result, block)
@@ -128,6 +124,34 @@ func parseContentBlocks(raw json.RawMessage) []ContentBlock {
 	return result
 }
 
+// formatToolResult renders tool_result content, which is either a plain
+// string or an array of content blocks, as display text
+func formatToolResult(raw json.RawMessage) string {
+	var str string
+	if err := json.Unmarshal(raw, &str); err == nil {
+		return str
+	}
+
+	var parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(raw, &parts); err == nil {
+		var texts []string
+		for _, p := range parts {
+			switch p.Type {
+			case "text":
+				texts = append(texts, p.Text)
+			default:
+				texts = append(texts, "["+p.Type+"]")
+			}
+		}
+		return strings.Join(texts, "\n")
+	}
+
+	return formatJSON(raw)
+}
+
 // formatJSON formats JSON for display
 func formatJSON(raw json.RawMessage) string {
 	// Try to pretty print
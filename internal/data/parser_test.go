@@ -1,6 +1,7 @@
 package data
 
 import (
+	"path/filepath"
 	"testing"
 )
 
@@ -78,3 +79,200 @@ func TestParseMessageLine_SkipsSnapshot(t *testing.T) {
 		t.Errorf("expected nil for snapshot, got message")
 	}
 }
+
+func TestParseMessageLine_ToolResult(t *testing.T) {
+	line := `{"type":"user","uuid":"jkl012","timestamp":"2025-12-22T22:20:50.146Z","sessionId":"session1","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_123","is_error":true,"content":"exit 1\nno such file"}]}}`
+
+	msg, err := ParseMessageLine([]byte(line))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(msg.Blocks) != 1 {
+		t.Fatalf("expected 1 block, got %d", len(msg.Blocks))
+	}
+	b := msg.Blocks[0]
+	if b.ToolID != "toolu_123" {
+		t.Errorf("expected tool id 'toolu_123', got %q", b.ToolID)
+	}
+	if !b.IsError {
+		t.Errorf("expected is_error to be set")
+	}
+	if b.Result != "exit 1\nno such file" {
+		t.Errorf("expected plain result text, got %q", b.Result)
+	}
+}
+
+func TestParseEntryLine_KnownTypes(t *testing.T) {
+	tests := []struct {
+		line     string
+		wantType string
+		describe string
+	}{
+		{`{"type":"summary","summary":"Fix login bug","leafUuid":"abc"}`, EntrySummary, "summary: Fix login bug"},
+		{`{"type":"system","subtype":"compact_boundary","uuid":"s1","timestamp":"2025-12-22T22:20:39.768Z","content":"Conversation compacted","compactMetadata":{"trigger":"auto","preTokens":155000}}`, EntrySystem, "compacted (auto, 155000 tokens before)"},
+		{`{"type":"file-history-snapshot","messageId":"m1","snapshot":{"messageId":"m1","trackedFileBackups":{"a.go":{},"b.go":{}},"timestamp":"2025-12-22T22:20:39.768Z"}}`, EntryFileHistorySnapshot, "file snapshot: 2 files"},
+		{`{"type":"queue-operation","operation":"enqueue","timestamp":"2025-12-22T22:20:39.768Z","content":"also run tests"}`, EntryQueueOperation, "queue enqueue: also run tests"},
+		{`{"type":"brand-new-thing","uuid":"x1"}`, "brand-new-thing", "unknown entry: brand-new-thing"},
+	}
+
+	for _, tt := range tests {
+		entry, err := ParseEntryLine([]byte(tt.line))
+		if err != nil {
+			t.Fatalf("unexpected error for %s: %v", tt.wantType, err)
+		}
+		if entry.EntryType() != tt.wantType {
+			t.Errorf("expected type %q, got %q", tt.wantType, entry.EntryType())
+		}
+		ev, ok := entry.(Event)
+		if !ok {
+			t.Fatalf("expected %s to be an Event", tt.wantType)
+		}
+		if ev.Describe() != tt.describe {
+			t.Errorf("expected description %q, got %q", tt.describe, ev.Describe())
+		}
+	}
+}
+
+func TestParseEntryLine_Message(t *testing.T) {
+	line := `{"type":"user","uuid":"abc123","sessionId":"session1","message":{"role":"user","content":"Hello"}}`
+
+	entry, err := ParseEntryLine([]byte(line))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	msg, ok := entry.(*Message)
+	if !ok {
+		t.Fatalf("expected *Message, got %T", entry)
+	}
+	if msg.EntryID() != "abc123" {
+		t.Errorf("expected id 'abc123', got %q", msg.EntryID())
+	}
+}
+
+// Each fixture in testdata/schema is a session written by one format
+// version. Loading it must go through that version's adapter, produce the
+// normalised fields and report no diagnostics.
+func TestSchemaFixtures(t *testing.T) {
+	tests := []struct {
+		file    string
+		adapter string
+		check   func(t *testing.T, s *Session)
+	}{
+		{"legacy.jsonl", "legacy", func(t *testing.T, s *Session) {
+			a := s.Messages[1]
+			if a.CostUSD != 0.00096 || a.DurationMs != 2150 {
+				t.Errorf("expected recorded cost and duration, got %v %v", a.CostUSD, a.DurationMs)
+			}
+			if a.Model != "claude-3-7-sonnet-20250219" || a.OutputTokens != 40 {
+				t.Errorf("unexpected model/usage %q %d", a.Model, a.OutputTokens)
+			}
+		}},
+		{"v1.jsonl", "1.0", func(t *testing.T, s *Session) {
+			if s.Messages[0].ThinkingLevel != "medium" {
+				t.Errorf("expected thinking level medium, got %q", s.Messages[0].ThinkingLevel)
+			}
+			if len(s.ToolCalls) != 1 || !s.ToolCalls[0].Done() || s.ToolCalls[0].Result.Result != "package app" {
+				t.Errorf("expected the Read call paired with its result")
+			}
+			if s.Messages[1].CacheReadTokens != 9000 || s.GitBranch != "main" {
+				t.Errorf("unexpected cache usage or branch")
+			}
+		}},
+		{"v2.jsonl", "2.0", func(t *testing.T, s *Session) {
+			if s.Messages[0].ThinkingLevel != "high" {
+				t.Errorf("expected thinking budget mapped to high, got %q", s.Messages[0].ThinkingLevel)
+			}
+			if s.Messages[2].ResultAgentID != "a1b2c3d4" {
+				t.Errorf("expected spawned agent id, got %q", s.Messages[2].ResultAgentID)
+			}
+			if s.Messages[2].Blocks[0].Result != "users, orders" {
+				t.Errorf("expected array tool result flattened, got %q", s.Messages[2].Blocks[0].Result)
+			}
+			sys, ok := s.Entries[len(s.Entries)-1].(*SystemEntry)
+			if !ok || !sys.IsCompaction() {
+				t.Errorf("expected a compaction boundary, got %T", s.Entries[len(s.Entries)-1])
+			}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			session := &Session{FilePath: filepath.Join("testdata", "schema", tt.file)}
+			if err := LoadSession(session); err != nil {
+				t.Fatalf("LoadSession: %v", err)
+			}
+			if len(session.Messages) == 0 {
+				t.Fatal("no messages parsed")
+			}
+			for _, msg := range session.Messages {
+				if msg.Schema != tt.adapter {
+					t.Errorf("%s: expected adapter %q, got %q", msg.UUID, tt.adapter, msg.Schema)
+				}
+			}
+			if len(session.Diagnostics) != 0 {
+				t.Errorf("unexpected diagnostics %v", session.Diagnostics)
+			}
+			tt.check(t, session)
+		})
+	}
+}
+
+func TestSchemaFixtures_UnknownVersion(t *testing.T) {
+	session := &Session{FilePath: filepath.Join("testdata", "schema", "future.jsonl")}
+	if err := LoadSession(session); err != nil {
+		t.Fatalf("LoadSession: %v", err)
+	}
+	if len(session.Messages) != 1 || session.Messages[0].Blocks[0].Text != "hello from the future" {
+		t.Fatalf("expected the entry parsed with the newest adapter")
+	}
+	if got := session.Messages[0].Schema; got != "2.0" {
+		t.Errorf("expected the 2.0 adapter, got %q", got)
+	}
+	if len(session.Diagnostics) != 1 || session.Diagnostics[0].Kind != DiagUnknownVersion {
+		t.Errorf("expected an unknown schema version diagnostic, got %v", session.Diagnostics)
+	}
+}
+
+// The same fields mean different things, or nothing, depending on the
+// release that wrote them
+func TestSchemaAdapters_VersionDifferences(t *testing.T) {
+	tests := []struct {
+		version, adapter, level string
+		cost                    float64
+	}{
+		{"", "legacy", "", 0.5},
+		{"0.2.9", "legacy", "", 0.5},
+		{"1.0.51", "1.0", "", 0},
+		{"2.0.14", "2.0", "high", 0},
+	}
+	for _, tt := range tests {
+		line := `{"type":"user","uuid":"u1","version":"` + tt.version + `","costUSD":0.5,"durationMs":900,"thinkingMetadata":{"maxThinkingTokens":31999},"message":{"role":"user","content":"ultrathink"}}`
+		msg, err := ParseMessageLine([]byte(line))
+		if err != nil {
+			t.Fatalf("%q: %v", tt.version, err)
+		}
+		if msg.Schema != tt.adapter || msg.ThinkingLevel != tt.level || msg.CostUSD != tt.cost {
+			t.Errorf("%q: expected adapter %q level %q cost %v, got %q %q %v", tt.version, tt.adapter, tt.level, tt.cost, msg.Schema, msg.ThinkingLevel, msg.CostUSD)
+		}
+	}
+}
+
+func TestParseCLIVersion(t *testing.T) {
+	tests := []struct {
+		in   string
+		want cliVersion
+		ok   bool
+	}{
+		{"1.0.51", cliVersion{1, 0, 51}, true},
+		{"2.0.0-beta.1", cliVersion{2, 0, 0}, true},
+		{"2.1", cliVersion{2, 1, 0}, true},
+		{"", cliVersion{}, false},
+		{"next", cliVersion{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseCLIVersion(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseCLIVersion(%q) = %v, %v; want %v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
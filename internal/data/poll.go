@@ -0,0 +1,198 @@
+package data
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+var (
+	// PollInterval is how often the polling backend rescans watched
+	// directories
+	PollInterval = 2 * time.Second
+	// ForcePolling makes NewWatcher poll even when native events are
+	// available, for filesystems that accept watches but never deliver
+	// events (NFS, sshfs, some container volumes)
+	ForcePolling = false
+)
+
+// PollBackend watches directories by listing them on an interval and
+// comparing sizes and modification times. It works where native events
+// don't, such as NFS, sshfs and bind-mounted container volumes. A rename
+// shows up as a Remove of the old name and a Create of the new one.
+type PollBackend struct {
+	interval time.Duration
+	mu       sync.Mutex
+	dirs     map[string]map[string]fileState // last listing of each watched directory
+	events   chan fsnotify.Event
+	errors   chan error
+	done     chan struct{}
+	closed   sync.Once
+}
+
+// fileState is what polling compares between listings
+type fileState struct {
+	size  int64
+	mtime time.Time
+	dir   bool
+}
+
+// NewPollBackend starts a backend that polls every interval
+func NewPollBackend(interval time.Duration) *PollBackend {
+	p := &PollBackend{
+		interval: interval,
+		dirs:     make(map[string]map[string]fileState),
+		events:   make(chan fsnotify.Event),
+		errors:   make(chan error),
+		done:     make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+// Add starts watching a directory's entries
+func (p *PollBackend) Add(path string) error {
+	listing, err := listDir(path)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	p.dirs[path] = listing
+	p.mu.Unlock()
+	return nil
+}
+
+// Remove stops watching a directory
+func (p *PollBackend) Remove(path string) error {
+	p.mu.Lock()
+	delete(p.dirs, path)
+	p.mu.Unlock()
+	return nil
+}
+
+func (p *PollBackend) Events() <-chan fsnotify.Event { return p.events }
+func (p *PollBackend) Errors() <-chan error          { return p.errors }
+
+// Close stops polling
+func (p *PollBackend) Close() error {
+	p.closed.Do(func() { close(p.done) })
+	return nil
+}
+
+func (p *PollBackend) run() {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if !p.poll() {
+				return
+			}
+		case <-p.done:
+			return
+		}
+	}
+}
+
+// poll lists every watched directory and reports what changed since the
+// last listing. It returns false once the backend is closed.
+func (p *PollBackend) poll() bool {
+	p.mu.Lock()
+	dirs := make([]string, 0, len(p.dirs))
+	for dir := range p.dirs {
+		dirs = append(dirs, dir)
+	}
+	p.mu.Unlock()
+	sort.Strings(dirs)
+
+	for _, dir := range dirs {
+		listing, err := listDir(dir)
+		if os.IsNotExist(err) {
+			// The listing of the parent reports the removal
+			p.Remove(dir)
+			continue
+		}
+		if err != nil {
+			if !p.sendError(err) {
+				return false
+			}
+			continue
+		}
+
+		p.mu.Lock()
+		prev, ok := p.dirs[dir]
+		if ok {
+			p.dirs[dir] = listing
+		}
+		p.mu.Unlock()
+		if !ok {
+			continue // removed meanwhile
+		}
+		for _, ev := range diffListings(dir, prev, listing) {
+			if !p.sendEvent(ev) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (p *PollBackend) sendEvent(ev fsnotify.Event) bool {
+	select {
+	case p.events <- ev:
+		return true
+	case <-p.done:
+		return false
+	}
+}
+
+func (p *PollBackend) sendError(err error) bool {
+	select {
+	case p.errors <- err:
+		return true
+	case <-p.done:
+		return false
+	}
+}
+
+// listDir records the state of each entry in dir
+func listDir(dir string) (map[string]fileState, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	listing := make(map[string]fileState, len(entries))
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			continue // removed since the listing
+		}
+		listing[entry.Name()] = fileState{size: info.Size(), mtime: info.ModTime(), dir: entry.IsDir()}
+	}
+	return listing, nil
+}
+
+// diffListings turns two listings of dir into events, in name order
+func diffListings(dir string, prev, next map[string]fileState) []fsnotify.Event {
+	var events []fsnotify.Event
+	for name, st := range next {
+		old, seen := prev[name]
+		switch {
+		case !seen || old.dir != st.dir:
+			events = append(events, fsnotify.Event{Name: filepath.Join(dir, name), Op: fsnotify.Create})
+		case !st.dir && (st.size != old.size || !st.mtime.Equal(old.mtime)):
+			events = append(events, fsnotify.Event{Name: filepath.Join(dir, name), Op: fsnotify.Write})
+		}
+	}
+	for name := range prev {
+		if _, ok := next[name]; !ok {
+			events = append(events, fsnotify.Event{Name: filepath.Join(dir, name), Op: fsnotify.Remove})
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Name < events[j].Name })
+	return events
+}
@@ -0,0 +1,205 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Price is a model's price in USD per million tokens
+type Price struct {
+	Input      float64 `json:"input"`
+	Output     float64 `json:"output"`
+	CacheRead  float64 `json:"cacheRead"`
+	CacheWrite float64 `json:"cacheWrite"`
+}
+
+// Cost returns the cost of the given usage in USD
+func (p Price) Cost(u TokenUsage) float64 {
+	return (float64(u.Input)*p.Input +
+		float64(u.Output)*p.Output +
+		float64(u.CacheRead)*p.CacheRead +
+		float64(u.CacheWrite)*p.CacheWrite) / 1e6
+}
+
+// PriceTable maps model names, or prefixes of them, to prices
+type PriceTable map[string]Price
+
+// DefaultPrices are the built-in list prices. Keys are model name prefixes
+// so dated releases (claude-sonnet-4-5-20250929) match their family.
+var DefaultPrices = PriceTable{
+	"claude-opus-4-5":   {Input: 5, Output: 25, CacheRead: 0.50, CacheWrite: 6.25},
+	"claude-opus-4-1":   {Input: 15, Output: 75, CacheRead: 1.50, CacheWrite: 18.75},
+	"claude-opus-4":     {Input: 15, Output: 75, CacheRead: 1.50, CacheWrite: 18.75},
+	"claude-sonnet-4":   {Input: 3, Output: 15, CacheRead: 0.30, CacheWrite: 3.75},
+	"claude-haiku-4-5":  {Input: 1, Output: 5, CacheRead: 0.10, CacheWrite: 1.25},
+	"claude-3-7-sonnet": {Input: 3, Output: 15, CacheRead: 0.30, CacheWrite: 3.75},
+	"claude-3-5-sonnet": {Input: 3, Output: 15, CacheRead: 0.30, CacheWrite: 3.75},
+	"claude-3-5-haiku":  {Input: 0.80, Output: 4, CacheRead: 0.08, CacheWrite: 1},
+	"claude-3-opus":     {Input: 15, Output: 75, CacheRead: 1.50, CacheWrite: 18.75},
+	"claude-3-haiku":    {Input: 0.25, Output: 1.25, CacheRead: 0.03, CacheWrite: 0.30},
+}
+
+// Prices is the table costs are computed with: the defaults plus any
+// overrides loaded with LoadPrices
+var Prices = DefaultPrices
+
+// Lookup returns the price for a model, matching the exact name first and
+// then the longest prefix
+func (t PriceTable) Lookup(model string) (Price, bool) {
+	if p, ok := t[model]; ok {
+		return p, true
+	}
+	best := ""
+	for key := range t {
+		if strings.HasPrefix(model, key) && len(key) > len(best) {
+			best = key
+		}
+	}
+	if best == "" {
+		return Price{}, false
+	}
+	return t[best], true
+}
+
+// Cost returns the cost of usage by a model, and false if the model has no price
+func (t PriceTable) Cost(model string, u TokenUsage) (float64, bool) {
+	p, ok := t.Lookup(model)
+	if !ok {
+		return 0, false
+	}
+	return p.Cost(u), true
+}
+
+// DefaultPricesPath returns the price override file under the user config
+// directory ($XDG_CONFIG_HOME/claude-mri on Linux)
+func DefaultPricesPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "claude-mri", "prices.json")
+}
+
+// LoadPrices reads a JSON object of model → price and layers it over the
+// built-in table. A missing file leaves the defaults in place.
+func LoadPrices(path string) error {
+	raw, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var overrides PriceTable
+	if err := json.Unmarshal(raw, &overrides); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	table := make(PriceTable, len(DefaultPrices)+len(overrides))
+	for model, p := range DefaultPrices {
+		table[model] = p
+	}
+	for model, p := range overrides {
+		table[model] = p
+	}
+	Prices = table
+	return nil
+}
+
+// Cost returns the message's cost in USD, and false if its model has no
+// price. A cost recorded by a legacy release stands in for a missing price.
+func (m *Message) Cost() (float64, bool) {
+	if m.Type != "assistant" {
+		return 0, false
+	}
+	if cost, ok := Prices.Cost(m.Model, m.Usage()); ok {
+		return cost, true
+	}
+	return m.CostUSD, m.CostUSD > 0
+}
+
+// Cost returns the bucket's cost in USD, and false if its model has no
+// price. All messages in a bucket share a model, so this equals the sum of
+// their Message.Cost, recorded legacy costs included.
+func (b UsageBucket) Cost() (float64, bool) {
+	if cost, ok := Prices.Cost(b.Model, b.Usage); ok {
+		return cost, true
+	}
+	return b.CostUSD, b.CostUSD > 0
+}
+
+// bucketsCost sums the cost of usage buckets
+func bucketsCost(buckets []UsageBucket) float64 {
+	var total float64
+	for _, b := range buckets {
+		c, _ := b.Cost()
+		total += c
+	}
+	return total
+}
+
+// Cost returns the session's own cost in USD, excluding subagents
+func (s *Session) Cost() float64 {
+	return bucketsCost(s.UsageBuckets())
+}
+
+// TotalCost returns the session's cost including the subagents it spawned
+func (s *Session) TotalCost() float64 {
+	total := s.Cost()
+	for _, agent := range s.Subagents {
+		total += agent.TotalCost()
+	}
+	return total
+}
+
+// Cost returns the cost of all of the project's sessions
+func (p *Project) Cost() float64 {
+	var total float64
+	for _, s := range p.Sessions {
+		total += s.Cost()
+	}
+	return total
+}
+
+// DailyCosts returns the project's cost per local day, keyed "2006-01-02"
+func (p *Project) DailyCosts() map[string]float64 {
+	days := make(map[string]float64)
+	for _, s := range p.Sessions {
+		s.addDailyCosts(days)
+	}
+	return days
+}
+
+// addDailyCosts adds the session's cost per local day to days. Loaded
+// sessions are split by message time, since an hourly bucket spans two
+// days in zones offset by a fraction of an hour; until then the index's
+// hourly buckets are all there is.
+func (s *Session) addDailyCosts(days map[string]float64) {
+	if !s.Loaded() {
+		for _, b := range s.UsageBuckets() {
+			c, _ := b.Cost()
+			days[b.Hour.Local().Format("2006-01-02")] += c
+		}
+		return
+	}
+	for _, msg := range s.Messages {
+		if c, ok := msg.Cost(); ok {
+			days[msg.Timestamp.Local().Format("2006-01-02")] += c
+		}
+	}
+}
+
+// FormatCost formats a USD amount for display
+func FormatCost(usd float64) string {
+	switch {
+	case usd == 0:
+		return "$0"
+	case usd < 0.01:
+		return "<$0.01"
+	case usd < 100:
+		return fmt.Sprintf("$%.2f", usd)
+	}
+	return fmt.Sprintf("$%.0f", usd)
+}
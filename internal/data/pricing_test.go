@@ -0,0 +1,150 @@
+package data
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPriceTable_Lookup(t *testing.T) {
+	tests := []struct {
+		model string
+		want  float64 // input price
+		ok    bool
+	}{
+		{"claude-opus-4-5-20251101", 5, true},
+		{"claude-opus-4-20250514", 15, true},
+		{"claude-sonnet-4-5-20250929", 3, true},
+		{"<synthetic>", 0, false},
+	}
+	for _, tt := range tests {
+		p, ok := DefaultPrices.Lookup(tt.model)
+		if ok != tt.ok || p.Input != tt.want {
+			t.Errorf("Lookup(%q) = %v, %v; want input %v, %v", tt.model, p.Input, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestSessionCost_SummaryMatchesLoaded(t *testing.T) {
+	defer func() { Prices = DefaultPrices }()
+	defer func(old *time.Location) { time.Local = old }(time.Local)
+	time.Local = time.UTC
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "s.jsonl")
+	lines := `{"type":"user","uuid":"u1","timestamp":"2025-01-02T10:00:00Z","message":{"role":"user","content":"go"}}
+{"type":"assistant","uuid":"a1","timestamp":"2025-01-02T10:00:01Z","message":{"id":"msg_1","role":"assistant","model":"claude-sonnet-4-20250514","content":"a","usage":{"input_tokens":1000,"output_tokens":100}}}
+{"type":"assistant","uuid":"a2","timestamp":"2025-01-02T10:00:02Z","message":{"id":"msg_1","role":"assistant","model":"claude-sonnet-4-20250514","content":"b","usage":{"input_tokens":1000,"output_tokens":200,"cache_read_input_tokens":5000}}}
+{"type":"assistant","uuid":"a3","timestamp":"2025-01-03T09:00:00Z","message":{"id":"msg_2","role":"assistant","model":"claude-sonnet-4-20250514","content":"c","usage":{"output_tokens":1000}}}
+`
+	if err := os.WriteFile(path, []byte(lines), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// 1000 in × $3 + 1200 out × $15 + 5000 cache read × $0.30, per million
+	want := (1000*3.0 + 1200*15.0 + 5000*0.30) / 1e6
+
+	ix, _ := OpenIndex("")
+	info, _ := os.Stat(path)
+	sum, err := ix.Summary(path, info)
+	if err != nil {
+		t.Fatalf("Summary: %v", err)
+	}
+	unloaded := &Session{FilePath: path, Summary: sum}
+	if got := unloaded.Cost(); math.Abs(got-want) > 1e-9 {
+		t.Errorf("summary cost = %v, want %v", got, want)
+	}
+
+	loaded := &Session{FilePath: path}
+	if err := LoadSession(loaded); err != nil {
+		t.Fatalf("LoadSession: %v", err)
+	}
+	if got := loaded.Cost(); math.Abs(got-want) > 1e-9 {
+		t.Errorf("loaded cost = %v, want %v", got, want)
+	}
+
+	project := &Project{Sessions: []*Session{loaded}}
+	if days := project.DailyCosts(); len(days) != 2 || math.Abs(days["2025-01-03"]-0.015) > 1e-9 {
+		t.Errorf("unexpected daily costs %v", days)
+	}
+
+	// Overrides replace the built-in price for that prefix
+	override := filepath.Join(dir, "prices.json")
+	os.WriteFile(override, []byte(`{"claude-sonnet-4":{"input":0,"output":0,"cacheRead":0,"cacheWrite":0}}`), 0o644)
+	if err := LoadPrices(override); err != nil {
+		t.Fatalf("LoadPrices: %v", err)
+	}
+	if got := loaded.Cost(); got != 0 {
+		t.Errorf("expected overridden price, got cost %v", got)
+	}
+}
+
+func TestSessionCost_RecordedCostsAndLocalDays(t *testing.T) {
+	defer func(old *time.Location) { time.Local = old }(time.Local)
+	time.Local = time.FixedZone("UTC+10", 10*3600)
+
+	// A legacy entry from a model without a price keeps its recorded cost
+	path := filepath.Join(t.TempDir(), "s.jsonl")
+	lines := `{"type":"assistant","uuid":"a1","timestamp":"2025-01-03T09:00:00Z","message":{"id":"msg_1","role":"assistant","model":"claude-sonnet-4-20250514","content":"a","usage":{"output_tokens":1000}}}
+{"type":"assistant","uuid":"a2","timestamp":"2025-01-03T20:00:00Z","costUSD":0.5,"message":{"id":"msg_2","role":"assistant","model":"claude-2.1","content":"b","usage":{"input_tokens":10}}}
+`
+	if err := os.WriteFile(path, []byte(lines), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := &Session{FilePath: path}
+	if err := LoadSession(loaded); err != nil {
+		t.Fatalf("LoadSession: %v", err)
+	}
+	var want float64
+	for _, msg := range loaded.Messages {
+		c, _ := msg.Cost()
+		want += c
+	}
+	if math.Abs(want-0.515) > 1e-9 {
+		t.Fatalf("unexpected message costs, sum %v", want)
+	}
+	if got := loaded.Cost(); math.Abs(got-want) > 1e-9 {
+		t.Errorf("loaded cost = %v, want the message sum %v", got, want)
+	}
+
+	ix, _ := OpenIndex("")
+	info, _ := os.Stat(path)
+	sum, err := ix.Summary(path, info)
+	if err != nil {
+		t.Fatalf("Summary: %v", err)
+	}
+	if got := (&Session{FilePath: path, Summary: sum}).Cost(); math.Abs(got-want) > 1e-9 {
+		t.Errorf("summary cost = %v, want %v", got, want)
+	}
+
+	// 20:00 UTC is the next morning in UTC+10
+	days := (&Project{Sessions: []*Session{loaded}}).DailyCosts()
+	if len(days) != 2 || math.Abs(days["2025-01-03"]-0.015) > 1e-9 || math.Abs(days["2025-01-04"]-0.5) > 1e-9 {
+		t.Errorf("unexpected daily costs %v", days)
+	}
+}
+
+func TestDailyCosts_HalfHourZone(t *testing.T) {
+	defer func(old *time.Location) { time.Local = old }(time.Local)
+	time.Local = time.FixedZone("UTC+5:30", 5*3600+1800)
+
+	// Both in the 18:00 UTC hour, either side of local midnight
+	path := filepath.Join(t.TempDir(), "s.jsonl")
+	lines := `{"type":"assistant","uuid":"a1","timestamp":"2025-01-03T18:15:00Z","costUSD":0.25,"message":{"id":"msg_1","role":"assistant","model":"claude-2.1","content":"a","usage":{"input_tokens":10}}}
+{"type":"assistant","uuid":"a2","timestamp":"2025-01-03T18:45:00Z","costUSD":0.5,"message":{"id":"msg_2","role":"assistant","model":"claude-2.1","content":"b","usage":{"input_tokens":10}}}
+`
+	if err := os.WriteFile(path, []byte(lines), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	session := &Session{FilePath: path}
+	if err := LoadSession(session); err != nil {
+		t.Fatalf("LoadSession: %v", err)
+	}
+	days := (&Project{Sessions: []*Session{session}}).DailyCosts()
+	if len(days) != 2 || math.Abs(days["2025-01-03"]-0.25) > 1e-9 || math.Abs(days["2025-01-04"]-0.5) > 1e-9 {
+		t.Errorf("expected the hour split at local midnight, got %v", days)
+	}
+}
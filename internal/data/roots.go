@@ -0,0 +1,87 @@
+package data
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Root is a labelled projects directory, such as this machine's
+// ~/.claude/projects or a copy exported from another machine
+type Root struct {
+	Label string
+	Path  string
+}
+
+// DefaultRoots returns ~/.claude/projects, plus the projects directory of
+// CLAUDE_CONFIG_DIR when that points at another profile
+func DefaultRoots() []Root {
+	home, _ := os.UserHomeDir()
+	roots := []Root{{Label: "local", Path: filepath.Join(home, ".claude", "projects")}}
+	if dir := os.Getenv("CLAUDE_CONFIG_DIR"); dir != "" {
+		roots = append(roots, Root{Label: filepath.Base(dir), Path: filepath.Join(dir, "projects")})
+	}
+	return UniqueRoots(roots)
+}
+
+// UniqueRoots drops roots whose path repeats an earlier one and makes
+// labels unique, so every project and session belongs to exactly one root
+func UniqueRoots(roots []Root) []Root {
+	seenPath := make(map[string]bool)
+	seenLabel := make(map[string]int)
+	var out []Root
+	for _, r := range roots {
+		path := filepath.Clean(r.Path)
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		if seenPath[path] {
+			continue
+		}
+		seenPath[path] = true
+
+		label := r.Label
+		if label == "" {
+			label = filepath.Base(path)
+		}
+		seenLabel[label]++
+		if n := seenLabel[label]; n > 1 {
+			label = fmt.Sprintf("%s (%d)", label, n)
+		}
+		out = append(out, Root{Label: label, Path: path})
+	}
+	return out
+}
+
+// ScanRoots scans every root. Display names are made unique within each
+// root, and a root that can't be read is shown as an empty project carrying
+// the error rather than failing the whole scan.
+func ScanRoots(roots []Root, index *Index) []*Project {
+	return ScanRootsProgress(roots, index, nil)
+}
+
+// ScanRootsProgress scans like ScanRoots, calling progress with the number
+// of project folders scanned so far and found so far. The total grows as
+// each root is listed.
+func ScanRootsProgress(roots []Root, index *Index, progress func(done, total int)) []*Project {
+	var counter *scanProgress
+	if progress != nil {
+		counter = &scanProgress{report: progress}
+	}
+	var projects []*Project
+	for _, root := range roots {
+		found, err := scanProjects(root.Path, index, counter)
+		if err != nil {
+			found = []*Project{{
+				Name:        root.Label,
+				Path:        root.Path,
+				Diagnostics: []Diagnostic{{File: root.Path, Kind: DiagUnreadable, Err: err.Error(), Count: 1}},
+			}}
+		}
+		for _, p := range found {
+			p.Source = root.Label
+		}
+		projects = append(projects, found...)
+	}
+	return projects
+}
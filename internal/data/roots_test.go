@@ -0,0 +1,44 @@
+package data
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestScanRoots(t *testing.T) {
+	mine, theirs := t.TempDir(), t.TempDir()
+	for _, root := range []string{mine, theirs} {
+		dir := filepath.Join(root, "-src-app")
+		os.MkdirAll(dir, 0o755)
+		os.WriteFile(filepath.Join(dir, archivedID+".jsonl"), []byte(archivedSession), 0o644)
+	}
+
+	roots := UniqueRoots([]Root{
+		{Label: "local", Path: mine},
+		{Label: "local", Path: theirs},
+		{Label: "again", Path: mine + "/"},
+		{Label: "missing", Path: filepath.Join(mine, "nope")},
+	})
+	if len(roots) != 3 || roots[1].Label != "local (2)" {
+		t.Fatalf("expected duplicate path dropped and labels made unique, got %+v", roots)
+	}
+
+	var done, total int
+	projects := ScanRootsProgress(roots, nil, func(d, t int) { done, total = d, t })
+	if done != 2 || total != 2 {
+		t.Errorf("expected progress to end at 2/2, got %d/%d", done, total)
+	}
+	if len(projects) != 3 {
+		t.Fatalf("expected a project per root, got %d", len(projects))
+	}
+	if projects[0].Source != "local" || projects[1].Source != "local (2)" {
+		t.Errorf("unexpected sources %q %q", projects[0].Source, projects[1].Source)
+	}
+	if projects[0].Sessions[0].FilePath == projects[1].Sessions[0].FilePath {
+		t.Errorf("expected sessions with the same id to stay distinct across roots")
+	}
+	if projects[2].Source != "missing" || len(projects[2].Diagnostics) != 1 {
+		t.Errorf("expected the unreadable root reported, got %+v", projects[2])
+	}
+}
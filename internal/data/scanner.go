@@ -1,74 +1,163 @@
 package data
 
 import (
-	"bufio"
 	"os"
 	"path/filepath"
 	"regexp"
 	"sort"
-	"strings"
 
 	"github.com/natdempk/claude-mri/internal/debug"
 )
 
 var (
-	// Matches session files: uuid.jsonl
-	sessionFileRe = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\.jsonl$`)
-	// Matches agent files: agent-xxx.jsonl
-	agentFileRe = regexp.MustCompile(`^agent-([a-f0-9]+)\.jsonl$`)
+	// Matches session files: uuid.jsonl, optionally .gz or .zst compressed
+	sessionFileRe = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\.jsonl(\.gz|\.zst)?$`)
+	// Matches agent files: agent-xxx.jsonl, optionally compressed
+	agentFileRe = regexp.MustCompile(`^agent-([a-f0-9]+)\.jsonl(?:\.gz|\.zst)?$`)
+	// Matches per-session directories: uuid/
+	sessionDirRe = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
 )
 
+// isSessionFile returns true for the session and agent file names the
+// scanner picks up, compressed or not
+func isSessionFile(name string) bool {
+	return sessionFileRe.MatchString(name) || agentFileRe.MatchString(name)
+}
+
 // ScanProjects scans the Claude projects directory and returns all projects
 func ScanProjects(basePath string) ([]*Project, error) {
+	return ScanProjectsIndexed(basePath, nil)
+}
+
+// ScanProjectsIndexed scans like ScanProjects, taking session metadata from
+// the index instead of reading files that have not changed. A nil index
+// falls back to peeking at the start of each file.
+//
+// basePath may also be a .tar or .tar.gz of a projects directory, and
+// tarballs inside basePath are opened as read-only projects alongside it.
+func ScanProjectsIndexed(basePath string, index *Index) ([]*Project, error) {
+	return scanProjects(basePath, index, nil)
+}
+
+// scanProgress counts scanned project folders for a progress callback. A
+// nil *scanProgress reports nothing.
+type scanProgress struct {
+	done, total int
+	report      func(done, total int)
+}
+
+// found adds project folders still to be scanned
+func (p *scanProgress) found(n int) {
+	if p != nil && n > 0 {
+		p.total += n
+		p.report(p.done, p.total)
+	}
+}
+
+// scanned marks one project folder as scanned
+func (p *scanProgress) scanned() {
+	if p != nil {
+		p.done++
+		p.report(p.done, p.total)
+	}
+}
+
+func scanProjects(basePath string, index *Index, progress *scanProgress) ([]*Project, error) {
 	defer debug.Time("ScanProjects")()
 
+	if IsArchive(basePath) {
+		root, err := OpenArchiveRoot(basePath)
+		if err != nil {
+			return nil, err
+		}
+		projects, err := scanRoot(root, index, basePath, progress)
+		if err != nil {
+			return nil, err
+		}
+		sortProjects(projects)
+		return projects, nil
+	}
+
+	projects, err := scanRoot(basePath, index, "", progress)
+	if err != nil {
+		return nil, err
+	}
+	sortProjects(projects)
+
+	debug.Log("ScanProjects found %d projects", len(projects))
+	return projects, nil
+}
+
+// scanRoot scans the project folders of one root directory. Archives found
+// in it are extracted and scanned too.
+func scanRoot(basePath string, index *Index, archive string, progress *scanProgress) ([]*Project, error) {
 	entries, err := os.ReadDir(basePath)
 	if err != nil {
 		return nil, err
 	}
+	dirs := 0
+	for _, entry := range entries {
+		if entry.IsDir() {
+			dirs++
+		}
+	}
+	progress.found(dirs)
 
 	var projects []*Project
 	for _, entry := range entries {
 		if !entry.IsDir() {
+			if archive == "" && IsArchive(entry.Name()) {
+				projects = append(projects, scanArchive(filepath.Join(basePath, entry.Name()), index, progress)...)
+			}
 			continue
 		}
 		projPath := filepath.Join(basePath, entry.Name())
 		proj := &Project{
-			Name: decodeProjectName(entry.Name()),
-			Path: projPath,
+			Path:    projPath,
+			Archive: archive,
 		}
 
-		// Scan sessions
-		sessions, err := scanSessions(projPath)
+		// Scan sessions; unreadable projects are kept with a diagnostic
+		sessions, err := scanSessions(projPath, index)
 		if err != nil {
-			continue // skip projects we can't read
+			proj.Diagnostics = append(proj.Diagnostics, Diagnostic{File: projPath, Kind: DiagUnreadable, Err: err.Error(), Count: 1})
 		}
 		proj.Sessions = sessions
+		proj.Cwd = projectCwd(proj)
 		projects = append(projects, proj)
+		progress.scanned()
 	}
-
-	// Sort projects by name
-	sort.Slice(projects, func(i, j int) bool {
-		return projects[i].Name < projects[j].Name
-	})
-
-	debug.Log("ScanProjects found %d projects", len(projects))
 	return projects, nil
 }
 
-// decodeProjectName converts folder name to readable project name
-// e.g., "C--Users-Nat-source-beads" -> "beads"
-func decodeProjectName(name string) string {
-	// Take the last path segment
-	parts := strings.Split(name, "-")
-	if len(parts) > 0 {
-		return parts[len(parts)-1]
+// scanArchive opens a tarball of project folders. A broken archive is shown
+// as an empty project carrying the error.
+func scanArchive(path string, index *Index, progress *scanProgress) []*Project {
+	root, err := OpenArchiveRoot(path)
+	if err == nil {
+		var projects []*Project
+		if projects, err = scanRoot(root, index, path, progress); err == nil {
+			return projects
+		}
 	}
-	return name
+	return []*Project{{
+		Path:        path,
+		Cwd:         filepath.Base(path),
+		Archive:     path,
+		Diagnostics: []Diagnostic{{File: path, Kind: DiagUnreadable, Err: err.Error(), Count: 1}},
+	}}
+}
+
+// sortProjects assigns display names and sorts projects by them
+func sortProjects(projects []*Project) {
+	assignDisplayNames(projects)
+	sort.Slice(projects, func(i, j int) bool {
+		return projects[i].Name < projects[j].Name
+	})
 }
 
 // scanSessions finds all session files in a project directory
-func scanSessions(projPath string) ([]*Session, error) {
+func scanSessions(projPath string, index *Index) ([]*Session, error) {
 	entries, err := os.ReadDir(projPath)
 	if err != nil {
 		return nil, err
@@ -77,6 +166,10 @@ func scanSessions(projPath string) ([]*Session, error) {
 	var sessions []*Session
 	for _, entry := range entries {
 		if entry.IsDir() {
+			// Newer releases keep agents in <session-id>/subagents/
+			if sessionDirRe.MatchString(entry.Name()) {
+				sessions = append(sessions, scanSubagentDir(filepath.Join(projPath, entry.Name(), "subagents"), entry.Name(), index)...)
+			}
 			continue
 		}
 		name := entry.Name()
@@ -85,7 +178,7 @@ func scanSessions(projPath string) ([]*Session, error) {
 		var session *Session
 		if sessionFileRe.MatchString(name) {
 			// Main session file
-			id := strings.TrimSuffix(name, ".jsonl")
+			id := TrimSessionExt(name)
 			session = &Session{
 				ID:       id,
 				FilePath: filePath,
@@ -106,45 +199,96 @@ func scanSessions(projPath string) ([]*Session, error) {
 			if info != nil {
 				session.UpdatedAt = info.ModTime()
 			}
+			describeSession(session, info, index)
 			sessions = append(sessions, session)
 		}
 	}
 
+	sessions = dropArchivedDuplicates(sessions)
+
 	// Sort by update time, newest first
 	sort.Slice(sessions, func(i, j int) bool {
 		return sessions[i].UpdatedAt.After(sessions[j].UpdatedAt)
 	})
 
+	linkSubagents(sessions)
 	return sessions, nil
 }
 
-// LoadSession loads all messages from a session file
-func LoadSession(session *Session) error {
-	defer debug.Time("LoadSession " + session.ID)()
+// dropArchivedDuplicates drops compressed copies of sessions that are also
+// present uncompressed, so each session appears once
+func dropArchivedDuplicates(sessions []*Session) []*Session {
+	plain := make(map[string]bool)
+	for _, s := range sessions {
+		if !IsCompressed(s.FilePath) {
+			plain[s.ID] = true
+		}
+	}
+	kept := sessions[:0]
+	for _, s := range sessions {
+		if IsCompressed(s.FilePath) && plain[s.ID] {
+			continue
+		}
+		kept = append(kept, s)
+	}
+	return kept
+}
 
-	file, err := os.Open(session.FilePath)
-	if err != nil {
-		return err
+// describeSession fills in session metadata from the index, or by peeking
+// at the start of the file when there is no index
+func describeSession(session *Session, info os.FileInfo, index *Index) {
+	if index != nil && info != nil {
+		if summary, err := index.Summary(session.FilePath, info); err == nil {
+			session.applySummary(summary)
+			return
+		}
 	}
-	defer file.Close()
+	peekSession(session)
+}
 
-	scanner := bufio.NewScanner(file)
-	// Increase buffer for large lines
-	buf := make([]byte, 0, 64*1024)
-	scanner.Buffer(buf, 1024*1024)
+// scanSubagentDir finds agent files stored under a session's subagents directory
+func scanSubagentDir(dir, parentID string, index *Index) []*Session {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
 
-	lineCount := 0
-	for scanner.Scan() {
-		lineCount++
-		msg, err := ParseMessageLine(scanner.Bytes())
-		if err != nil {
-			continue // skip malformed lines
+	var sessions []*Session
+	for _, entry := range entries {
+		matches := agentFileRe.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || matches == nil {
+			continue
+		}
+		session := &Session{
+			ID:       matches[1],
+			FilePath: filepath.Join(dir, entry.Name()),
+			IsAgent:  true,
+			AgentID:  matches[1],
 		}
-		if msg != nil {
-			session.Messages = append(session.Messages, msg)
+		info, _ := entry.Info()
+		if info != nil {
+			session.UpdatedAt = info.ModTime()
 		}
+		describeSession(session, info, index)
+		if session.ParentSessionID == "" {
+			session.ParentSessionID = parentID
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions
+}
+
+// LoadSession loads all messages from a session file, replacing any
+// previously loaded messages
+func LoadSession(session *Session) error {
+	defer debug.Time("LoadSession " + session.ID)()
+
+	tail, err := readTail(session.TailPosition(), true)
+	if err != nil {
+		return err
 	}
+	session.ApplyTail(tail)
 
-	debug.Log("LoadSession parsed %d lines, %d messages", lineCount, len(session.Messages))
-	return scanner.Err()
+	debug.Log("LoadSession read %d bytes, %d messages", session.Offset, len(session.Messages))
+	return nil
 }
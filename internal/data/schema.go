@@ -0,0 +1,172 @@
+package data
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// cliVersion is a parsed Claude Code release number, e.g. 1.0.51
+type cliVersion [3]int
+
+// parseCLIVersion parses "major.minor.patch", ignoring any pre-release
+// suffix. Missing parts are zero.
+func parseCLIVersion(s string) (cliVersion, bool) {
+	var v cliVersion
+	if s == "" {
+		return v, false
+	}
+	if i := strings.IndexAny(s, "-+ "); i >= 0 {
+		s = s[:i]
+	}
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return v, false
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return cliVersion{}, false
+		}
+		v[i] = n
+	}
+	return v, true
+}
+
+func (v cliVersion) less(o cliVersion) bool {
+	for i := range v {
+		if v[i] != o[i] {
+			return v[i] < o[i]
+		}
+	}
+	return false
+}
+
+// entryFields are the version-dependent fields of a message entry, read
+// once and handed to the schema adapter
+type entryFields struct {
+	ThinkingMetadata json.RawMessage `json:"thinkingMetadata"`
+	ToolUseResult    json.RawMessage `json:"toolUseResult"`
+	CostUSD          float64         `json:"costUSD"`
+	DurationMs       int             `json:"durationMs"`
+	Message          *struct {
+		Model      string `json:"model"`
+		StopReason string `json:"stop_reason"`
+		Usage      *struct {
+			InputTokens              int `json:"input_tokens"`
+			OutputTokens             int `json:"output_tokens"`
+			CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+			CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+		} `json:"usage"`
+	} `json:"message"`
+}
+
+// schemaAdapter normalises message entries written by a range of CLI
+// releases into the current model
+type schemaAdapter struct {
+	name  string
+	since cliVersion // first release writing this shape
+	adapt func(msg *Message, f *entryFields)
+}
+
+// schemaAdapters are ordered oldest first; an entry uses the last adapter
+// whose since is not after its version. Entries without a version predate
+// version stamping and use the first. See testdata/schema for a session
+// in each format.
+var schemaAdapters = []schemaAdapter{
+	// Early releases recorded the CLI's own cost and latency estimate on
+	// each assistant entry and had no thinking metadata
+	{name: "legacy", since: cliVersion{0, 0, 0}, adapt: func(msg *Message, f *entryFields) {
+		msg.CostUSD = f.CostUSD
+		msg.DurationMs = f.DurationMs
+	}},
+	// 1.0 dropped the per-entry cost and added thinkingMetadata.level
+	{name: "1.0", since: cliVersion{1, 0, 0}, adapt: func(msg *Message, f *entryFields) {
+		msg.ThinkingLevel = thinkingMetadata(f).namedLevel()
+	}},
+	// 2.0 can record the thinking token budget instead of a named level
+	{name: "2.0", since: cliVersion{2, 0, 0}, adapt: func(msg *Message, f *entryFields) {
+		meta := thinkingMetadata(f)
+		msg.ThinkingLevel = meta.namedLevel()
+		if msg.ThinkingLevel == "" && !meta.Disabled {
+			msg.ThinkingLevel = budgetLevel(meta.MaxThinkingTokens)
+		}
+	}},
+}
+
+// knownSchemaMajor is the newest major release with an adapter; entries
+// from later majors are parsed but reported as a diagnostic
+var knownSchemaMajor = schemaAdapters[len(schemaAdapters)-1].since[0]
+
+// schemaFor returns the adapter for entries written by version
+func schemaFor(version string) *schemaAdapter {
+	v, ok := parseCLIVersion(version)
+	if !ok {
+		return &schemaAdapters[0]
+	}
+	adapter := &schemaAdapters[0]
+	for i := range schemaAdapters {
+		if !v.less(schemaAdapters[i].since) {
+			adapter = &schemaAdapters[i]
+		}
+	}
+	return adapter
+}
+
+// adaptEntry routes a message entry through the adapter for its CLI
+// version, recording which one it was
+func adaptEntry(msg *Message, f *entryFields) {
+	adapter := schemaFor(msg.Version)
+	msg.Schema = adapter.name
+	adapter.adapt(msg, f)
+}
+
+// thinkingBudgets maps thinking token budgets to the level names of the
+// "think", "think hard" and "ultrathink" triggers
+var thinkingBudgets = []struct {
+	tokens int
+	level  string
+}{
+	{31999, "high"},
+	{10000, "medium"},
+	{4000, "low"},
+}
+
+// thinkingMeta is the thinkingMetadata of a user entry
+type thinkingMeta struct {
+	Level             string `json:"level"`
+	Disabled          bool   `json:"disabled"`
+	MaxThinkingTokens int    `json:"maxThinkingTokens"`
+}
+
+func thinkingMetadata(f *entryFields) thinkingMeta {
+	var meta thinkingMeta
+	if len(f.ThinkingMetadata) > 0 {
+		json.Unmarshal(f.ThinkingMetadata, &meta)
+	}
+	return meta
+}
+
+// namedLevel returns the thinking level, or "" when thinking was off
+func (m thinkingMeta) namedLevel() string {
+	if m.Disabled || m.Level == "none" {
+		return ""
+	}
+	return m.Level
+}
+
+// budgetLevel maps a thinking token budget to the level it was triggered by
+func budgetLevel(tokens int) string {
+	for _, b := range thinkingBudgets {
+		if tokens >= b.tokens {
+			return b.level
+		}
+	}
+	return ""
+}
+
+// unknownSchemaVersion returns true for versions newer than any adapter
+func unknownSchemaVersion(version string) bool {
+	v, ok := parseCLIVersion(version)
+	return ok && v[0] > knownSchemaMajor
+}
@@ -0,0 +1,110 @@
+package data
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// isTaskTool returns true for tools that spawn a subagent
+func isTaskTool(name string) bool {
+	return name == "Task" || name == "Agent"
+}
+
+// messageText returns the concatenated text blocks of a message
+func messageText(msg *Message) string {
+	var parts []string
+	for _, b := range msg.Blocks {
+		if b.Type == "text" && b.Text != "" {
+			parts = append(parts, b.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// linkSubagents attaches agent sessions to the main session that spawned
+// them (matched by the agent's sessionId) and resolves their Task calls
+func linkSubagents(sessions []*Session) {
+	byID := make(map[string]*Session)
+	for _, s := range sessions {
+		s.Subagents = nil
+		if !s.IsAgent {
+			byID[s.ID] = s
+		}
+	}
+	for _, s := range sessions {
+		s.Parent = nil
+		if !s.IsAgent || s.ParentSessionID == "" {
+			continue
+		}
+		if parent, ok := byID[s.ParentSessionID]; ok {
+			s.Parent = parent
+			parent.Subagents = append(parent.Subagents, s)
+		}
+	}
+	for _, s := range sessions {
+		s.resolveTaskAgents()
+	}
+}
+
+// resolveTaskAgents matches the session's Task tool calls to its subagents,
+// by the agentId recorded on the tool result or, failing that, by prompt
+func (s *Session) resolveTaskAgents() {
+	if len(s.Subagents) == 0 {
+		return
+	}
+	byAgentID := make(map[string]*Session, len(s.Subagents))
+	for _, a := range s.Subagents {
+		byAgentID[a.AgentID] = a
+	}
+	for _, call := range s.ToolCalls {
+		if !isTaskTool(call.Name) {
+			continue
+		}
+		call.Agent = nil
+		if call.ResultMessage != nil && call.ResultMessage.ResultAgentID != "" {
+			call.AgentID = call.ResultMessage.ResultAgentID
+			call.Agent = byAgentID[call.AgentID]
+			continue
+		}
+		prompt := taskPrompt(call)
+		if prompt == "" {
+			continue
+		}
+		for _, a := range s.Subagents {
+			if a.Prompt == prompt {
+				call.Agent = a
+				call.AgentID = a.AgentID
+				break
+			}
+		}
+	}
+}
+
+// taskPrompt returns the prompt argument of a Task call
+func taskPrompt(call *ToolCall) string {
+	var input struct {
+		Prompt string `json:"prompt"`
+	}
+	if err := json.Unmarshal([]byte(call.Input), &input); err != nil {
+		return ""
+	}
+	return input.Prompt
+}
+
+// UnlinkedSubagents returns subagents not yet matched to a Task call,
+// e.g. because the parent session has not been loaded
+func (s *Session) UnlinkedSubagents() []*Session {
+	linked := make(map[*Session]bool)
+	for _, call := range s.ToolCalls {
+		if call.Agent != nil {
+			linked[call.Agent] = true
+		}
+	}
+	var unlinked []*Session
+	for _, a := range s.Subagents {
+		if !linked[a] {
+			unlinked = append(unlinked, a)
+		}
+	}
+	return unlinked
+}
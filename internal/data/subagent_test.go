@@ -0,0 +1,55 @@
+package data
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestScanSessions_LinksAgentToTaskCall(t *testing.T) {
+	dir := t.TempDir()
+	parentID := "11111111-2222-3333-4444-555555555555"
+	parent := `{"type":"assistant","uuid":"a1","sessionId":"` + parentID + `","message":{"role":"assistant","content":[{"type":"tool_use","id":"toolu_1","name":"Task","input":{"description":"explore","prompt":"Find the config loader"}}]}}
+{"type":"user","uuid":"u1","parentUuid":"a1","sessionId":"` + parentID + `","toolUseResult":{"agentId":"abc123","status":"completed"},"message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_1","content":"done"}]}}
+`
+	agent := `{"type":"user","uuid":"x1","sessionId":"` + parentID + `","agentId":"abc123","isSidechain":true,"message":{"role":"user","content":"Find the config loader"}}
+`
+	if err := os.WriteFile(filepath.Join(dir, parentID+".jsonl"), []byte(parent), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "agent-abc123.jsonl"), []byte(agent), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	sessions, err := scanSessions(dir, nil)
+	if err != nil {
+		t.Fatalf("scanSessions: %v", err)
+	}
+	var main, sub *Session
+	for _, s := range sessions {
+		if s.IsAgent {
+			sub = s
+		} else {
+			main = s
+		}
+	}
+	if main == nil || sub == nil {
+		t.Fatalf("expected a main and an agent session, got %d sessions", len(sessions))
+	}
+	if sub.Parent != main {
+		t.Fatalf("expected agent to be linked to its parent session")
+	}
+	if len(main.UnlinkedSubagents()) != 1 {
+		t.Errorf("expected agent to be unlinked before the parent is loaded")
+	}
+
+	if err := LoadSession(main); err != nil {
+		t.Fatalf("LoadSession: %v", err)
+	}
+	if len(main.ToolCalls) != 1 || main.ToolCalls[0].Agent != sub {
+		t.Fatalf("expected Task call to resolve to the agent session")
+	}
+	if len(main.UnlinkedSubagents()) != 0 {
+		t.Errorf("expected no unlinked agents after loading")
+	}
+}
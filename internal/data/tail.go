@@ -0,0 +1,255 @@
+package data
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+)
+
+// SessionTail holds the entries appended to a session file since it was last read
+type SessionTail struct {
+	Entries     []Entry
+	Messages    []*Message
+	Offset      int64 // new read offset
+	Reset       bool  // file was truncated or replaced; Messages is the full content
+	Lines       int   // complete lines read
+	Since       int64 // session offset the read started from
+	Diagnostics []Diagnostic
+	info        os.FileInfo
+}
+
+// TailPosition is how far a session has been read. Reads work from a copy,
+// so they can run off the UI goroutine while the session itself changes.
+type TailPosition struct {
+	FilePath string
+	Offset   int64
+	Lines    int
+	info     os.FileInfo
+}
+
+// TailPosition returns where the next read of the session starts
+func (s *Session) TailPosition() TailPosition {
+	return TailPosition{FilePath: s.FilePath, Offset: s.Offset, Lines: s.Lines, info: s.fileInfo}
+}
+
+// ReadSessionTail reads lines appended to the session file since session.Offset.
+// It does not modify the session; pass the result to Session.ApplyTail.
+// If the file was truncated or replaced (rotation), it rereads from the start
+// and marks the tail as a reset.
+func ReadSessionTail(session *Session) (*SessionTail, error) {
+	return readTail(session.TailPosition(), false)
+}
+
+// ReadTail is ReadSessionTail for a position taken earlier. The tail only
+// applies to a session still at that position; compare its Since with the
+// session's Offset.
+func ReadTail(pos TailPosition) (*SessionTail, error) {
+	return readTail(pos, false)
+}
+
+func readTail(pos TailPosition, forceReset bool) (*SessionTail, error) {
+	info, err := os.Stat(pos.FilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	tail := &SessionTail{info: info, Since: pos.Offset}
+	offset := pos.Offset
+	compressed := IsCompressed(pos.FilePath)
+	unchanged := pos.info != nil && os.SameFile(pos.info, info) &&
+		info.Size() == pos.info.Size() && info.ModTime().Equal(pos.info.ModTime())
+	if compressed && unchanged && !forceReset {
+		tail.Offset = offset
+		return tail, nil
+	}
+	if forceReset || compressed || pos.info == nil || !os.SameFile(pos.info, info) || info.Size() < offset {
+		tail.Reset = true
+		offset = 0
+	}
+
+	file, _, err := openSessionFile(pos.FilePath, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	lineNo := pos.Lines
+	if tail.Reset {
+		lineNo = 0
+	}
+
+	// ReadBytes grows as needed, so arbitrarily long lines (large tool
+	// results, images) are read whole instead of ending the session
+	reader := bufio.NewReaderSize(file, 64*1024)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			complete := line[len(line)-1] == '\n'
+			// A trailing line without newline may still be mid-write;
+			// only consume it if it is already valid JSON
+			if !complete && !json.Valid(bytes.TrimSpace(line)) {
+				break
+			}
+			lineStart := offset
+			offset += int64(len(line))
+			lineNo++
+			tail.Lines++
+			if len(bytes.TrimSpace(line)) == 0 {
+				continue
+			}
+			entry, perr := ParseEntryLine(line)
+			if perr != nil {
+				tail.Diagnostics = append(tail.Diagnostics, Diagnostic{
+					File:  pos.FilePath,
+					Line:  lineNo,
+					Kind:  DiagMalformed,
+					Err:   perr.Error(),
+					Count: 1,
+				})
+				continue
+			}
+			if entry != nil {
+				tail.Diagnostics = append(tail.Diagnostics, lineDiagnostics(line, entry, pos.FilePath, lineNo)...)
+				tail.Entries = append(tail.Entries, entry)
+				if msg, ok := entry.(*Message); ok {
+					// Offsets into a compressed file can't be read back later
+					if !compressed {
+						deferLargePayloads(msg, pos.FilePath, lineStart, len(line))
+					}
+					tail.Messages = append(tail.Messages, msg)
+				}
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tail.Offset = offset
+	return tail, nil
+}
+
+// ApplyTail appends (or, on reset, replaces) the session's messages with the tail
+func (s *Session) ApplyTail(tail *SessionTail) {
+	if tail.Reset {
+		s.Entries = nil
+		s.Messages = nil
+		s.Lines = 0
+		s.Diagnostics = nil
+		s.malformed = 0
+		s.ToolCalls = nil
+		s.toolCallsByID = make(map[string]*ToolCall)
+		s.turnsByAPIID = nil
+	}
+	if s.toolCallsByID == nil {
+		s.toolCallsByID = make(map[string]*ToolCall)
+	}
+
+	// Streamed chunks of one response become a single turn
+	entries, messages, changed := s.consolidate(tail.Entries)
+	for _, msg := range messages {
+		s.noteEnvironment(msg)
+	}
+	s.Entries = append(s.Entries, entries...)
+	s.Messages = append(s.Messages, messages...)
+	s.ToolCalls = append(s.ToolCalls, LinkToolCalls(changed, s.toolCallsByID)...)
+	s.ToolCalls = append(s.ToolCalls, LinkToolCalls(messages, s.toolCallsByID)...)
+	s.resolveTaskAgents()
+	s.graph = nil
+	s.Lines += tail.Lines
+	s.addDiagnostics(tail.Diagnostics)
+	s.Offset = tail.Offset
+	s.fileInfo = tail.info
+	if tail.info != nil {
+		s.UpdatedAt = tail.info.ModTime()
+	}
+}
+
+// ReuseLoadedSessions replaces freshly scanned sessions in next with their
+// already-loaded counterparts from prev (matched by file path), so a rescan
+// does not throw away parsed messages. Update times are taken from next.
+func ReuseLoadedSessions(prev, next []*Project) {
+	loaded := make(map[string]*Session)
+	for _, p := range prev {
+		for _, s := range p.Sessions {
+			if s.Loaded() {
+				loaded[s.FilePath] = s
+			}
+		}
+	}
+	if len(loaded) == 0 {
+		return
+	}
+	for _, p := range next {
+		reused := false
+		for i, s := range p.Sessions {
+			if old, ok := loaded[s.FilePath]; ok {
+				old.UpdatedAt = s.UpdatedAt
+				old.ParentSessionID = s.ParentSessionID
+				old.Prompt = s.Prompt
+				p.Sessions[i] = old
+				reused = true
+			}
+		}
+		if reused {
+			linkSubagents(p.Sessions)
+		}
+	}
+}
+
+// KeepDeletedSessions carries sessions marked Deleted from prev over into a
+// fresh scan, so their loaded content stays browsable until dismissed. A
+// session the scan found again, recreated or moved within its project, is
+// dropped in favour of the new file. Projects whose directory is gone are
+// kept while they hold deleted sessions.
+func KeepDeletedSessions(prev, next []*Project) []*Project {
+	byPath := make(map[string]*Project, len(next))
+	for _, p := range next {
+		byPath[p.Path] = p
+	}
+	for _, old := range prev {
+		var deleted []*Session
+		for _, s := range old.Sessions {
+			if s.Deleted {
+				deleted = append(deleted, s)
+			}
+		}
+		if len(deleted) == 0 {
+			continue
+		}
+		p, ok := byPath[old.Path]
+		if !ok {
+			p = &Project{Path: old.Path, Name: old.Name, Cwd: old.Cwd, Source: old.Source, Archive: old.Archive}
+			next = append(next, p)
+		}
+		found := make(map[string]bool, len(p.Sessions))
+		for _, s := range p.Sessions {
+			found[s.ID] = true
+		}
+		for _, s := range deleted {
+			if !found[s.ID] {
+				p.Sessions = append(p.Sessions, s)
+			}
+		}
+		linkSubagents(p.Sessions)
+	}
+	return next
+}
+
+// DismissSession removes a session from its project, returning false if
+// it wasn't found
+func (p *Project) DismissSession(session *Session) bool {
+	for i, s := range p.Sessions {
+		if s == session {
+			p.Sessions = append(p.Sessions[:i], p.Sessions[i+1:]...)
+			linkSubagents(p.Sessions)
+			return true
+		}
+	}
+	return false
+}
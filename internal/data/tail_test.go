@@ -0,0 +1,240 @@
+package data
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+const (
+	tailLine1 = `{"type":"user","uuid":"u1","message":{"role":"user","content":"one"}}` + "\n"
+	tailLine2 = `{"type":"assistant","uuid":"a1","message":{"role":"assistant","content":"two"}}` + "\n"
+)
+
+func writeSessionFile(t *testing.T, path, content string, flag int) {
+	t.Helper()
+	f, err := os.OpenFile(path, flag|os.O_WRONLY|os.O_CREATE, 0o644)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+}
+
+func TestReadSessionTail_AppendsOnlyNewLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "s.jsonl")
+	writeSessionFile(t, path, tailLine1, os.O_TRUNC)
+
+	session := &Session{ID: "s", FilePath: path}
+	if err := LoadSession(session); err != nil {
+		t.Fatalf("LoadSession: %v", err)
+	}
+	if len(session.Messages) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(session.Messages))
+	}
+
+	// A partially written line must not be consumed
+	writeSessionFile(t, path, tailLine2[:20], os.O_APPEND)
+	tail, err := ReadSessionTail(session)
+	if err != nil {
+		t.Fatalf("ReadSessionTail: %v", err)
+	}
+	if tail.Reset || len(tail.Messages) != 0 {
+		t.Fatalf("expected empty non-reset tail, got reset=%v messages=%d", tail.Reset, len(tail.Messages))
+	}
+	session.ApplyTail(tail)
+
+	writeSessionFile(t, path, tailLine2[20:], os.O_APPEND)
+	tail, err = ReadSessionTail(session)
+	if err != nil {
+		t.Fatalf("ReadSessionTail: %v", err)
+	}
+	if tail.Reset {
+		t.Errorf("expected incremental read, got reset")
+	}
+	session.ApplyTail(tail)
+	if len(session.Messages) != 2 || session.Messages[1].UUID != "a1" {
+		t.Fatalf("expected appended message a1, got %d messages", len(session.Messages))
+	}
+}
+
+func TestReadSessionTail_ResetsOnTruncation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "s.jsonl")
+	writeSessionFile(t, path, tailLine1+tailLine2, os.O_TRUNC)
+
+	session := &Session{ID: "s", FilePath: path}
+	if err := LoadSession(session); err != nil {
+		t.Fatalf("LoadSession: %v", err)
+	}
+
+	writeSessionFile(t, path, tailLine2, os.O_TRUNC)
+	tail, err := ReadSessionTail(session)
+	if err != nil {
+		t.Fatalf("ReadSessionTail: %v", err)
+	}
+	if !tail.Reset {
+		t.Fatalf("expected reset after truncation")
+	}
+	session.ApplyTail(tail)
+	if len(session.Messages) != 1 || session.Messages[0].UUID != "a1" {
+		t.Fatalf("expected only a1 after reset, got %d messages", len(session.Messages))
+	}
+}
+
+func TestLoadSession_LongLinesAndBadLines(t *testing.T) {
+	defer func(old int) { LazyPayloadThreshold = old }(LazyPayloadThreshold)
+	LazyPayloadThreshold = 64 * 1024
+
+	// A result well past the old 1 MiB scanner limit
+	big := strings.Repeat("x", 2<<20)
+	lines := `{"type":"user","uuid":"u1","message":{"role":"user","content":"read it"}}
+not json at all
+{"type":"user","uuid":"u2","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"` + big + `"}]}}
+{"type":"assistant","uuid":"a1","message":{"role":"assistant","content":"after the big line"}}
+`
+	path := filepath.Join(t.TempDir(), "s.jsonl")
+	writeSessionFile(t, path, lines, os.O_TRUNC)
+
+	session := &Session{ID: "s", FilePath: path}
+	if err := LoadSession(session); err != nil {
+		t.Fatalf("LoadSession: %v", err)
+	}
+	if len(session.Messages) != 3 {
+		t.Fatalf("expected 3 messages, got %d", len(session.Messages))
+	}
+	if session.BadLines() != 1 || len(session.Diagnostics) != 1 || session.Diagnostics[0].Line != 2 {
+		t.Errorf("expected line 2 reported as unparsable, got %d bad lines (%v)", session.BadLines(), session.Diagnostics)
+	}
+
+	block := &session.Messages[1].Blocks[0]
+	if block.Lazy == nil || len(block.Result) >= len(big) {
+		t.Fatalf("expected large result to be deferred")
+	}
+	if err := block.LoadPayload(); err != nil {
+		t.Fatalf("LoadPayload: %v", err)
+	}
+	if block.Result != big || block.Lazy != nil {
+		t.Errorf("expected full payload after loading, got %d bytes", len(block.Result))
+	}
+}
+
+func TestDeferLargePayloads_Preview(t *testing.T) {
+	defer func(old int) { LazyPayloadThreshold = old }(LazyPayloadThreshold)
+	LazyPayloadThreshold = 16
+
+	// Deferred although shorter than the preview
+	short := &Message{Blocks: []ContentBlock{{Type: "text", Text: strings.Repeat("é", 100)}}}
+	deferLargePayloads(short, "s.jsonl", 0, 1000)
+	if b := short.Blocks[0]; b.Lazy == nil || b.Text != strings.Repeat("é", 100) {
+		t.Errorf("expected the whole short payload as preview, got %d bytes", len(b.Text))
+	}
+
+	// A preview never ends mid-rune
+	long := &Message{Blocks: []ContentBlock{{Type: "text", Text: "x" + strings.Repeat("é", lazyPreviewSize)}}}
+	deferLargePayloads(long, "s.jsonl", 0, 3*lazyPreviewSize)
+	if b := long.Blocks[0]; !utf8.ValidString(b.Text) || len(b.Text) != lazyPreviewSize-1 {
+		t.Errorf("expected a %d byte valid preview, got %d bytes (valid %v)", lazyPreviewSize-1, len(b.Text), utf8.ValidString(b.Text))
+	}
+}
+
+func TestLoadSession_Diagnostics(t *testing.T) {
+	lines := `{"type":"user","uuid":"u1","newField":1,"message":{"role":"user","content":"hi"}}
+{"type":"teleport","uuid":"x1"}
+{"type":"user","uuid":"u2","newField":2,"message":{"role":"user","content":"again"}}
+{"type":"assistant","uuid":"a1","message":{"role":"assistant","content":[{"type":"hologram"}]}}
+`
+	path := filepath.Join(t.TempDir(), "s.jsonl")
+	writeSessionFile(t, path, lines, os.O_TRUNC)
+
+	session := &Session{ID: "s", FilePath: path}
+	if err := LoadSession(session); err != nil {
+		t.Fatalf("LoadSession: %v", err)
+	}
+
+	want := map[string]Diagnostic{
+		"unknown field user.newField": {Line: 1, Count: 2},
+		"unknown type teleport":       {Line: 2, Count: 1},
+		"unknown block hologram":      {Line: 4, Count: 1},
+	}
+	if len(session.Diagnostics) != len(want) {
+		t.Fatalf("expected %d diagnostics, got %v", len(want), session.Diagnostics)
+	}
+	for _, d := range session.Diagnostics {
+		w, ok := want[d.Kind.String()+" "+d.Detail]
+		if !ok || d.Line != w.Line || d.Count != w.Count || d.File != path {
+			t.Errorf("unexpected diagnostic %+v", d)
+		}
+	}
+	if session.BadLines() != 0 {
+		t.Errorf("expected no bad lines, got %d", session.BadLines())
+	}
+}
+
+func TestKeepDeletedSessions(t *testing.T) {
+	gone := &Session{ID: "gone", FilePath: "/p/gone.jsonl", Deleted: true}
+	moved := &Session{ID: "moved", FilePath: "/p/moved.jsonl", Deleted: true}
+	orphan := &Session{ID: "orphan", FilePath: "/q/orphan.jsonl", Deleted: true}
+	prev := []*Project{
+		{Path: "/p", Name: "p", Sessions: []*Session{gone, moved, {ID: "live", FilePath: "/p/live.jsonl"}}},
+		{Path: "/q", Name: "q", Sessions: []*Session{orphan}},
+	}
+	next := []*Project{
+		{Path: "/p", Name: "p", Sessions: []*Session{
+			{ID: "live", FilePath: "/p/live.jsonl"},
+			{ID: "moved", FilePath: "/p/moved.jsonl.gz"},
+		}},
+	}
+
+	next = KeepDeletedSessions(prev, next)
+	if len(next) != 2 || next[1].Path != "/q" || len(next[1].Sessions) != 1 || next[1].Sessions[0] != orphan {
+		t.Fatalf("expected project /q kept for its deleted session, got %+v", next)
+	}
+	var ids []string
+	for _, s := range next[0].Sessions {
+		ids = append(ids, s.ID)
+	}
+	if strings.Join(ids, ",") != "live,moved,gone" || next[0].Sessions[1].Deleted {
+		t.Errorf("expected deleted session kept and moved one replaced, got %v", ids)
+	}
+
+	if !next[0].DismissSession(gone) || len(next[0].Sessions) != 2 {
+		t.Errorf("expected dismissed session removed, got %d sessions", len(next[0].Sessions))
+	}
+}
+
+func TestReadTail_FromEarlierPosition(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "s.jsonl")
+	writeSessionFile(t, path, tailLine1, os.O_TRUNC)
+	session := &Session{ID: "s", FilePath: path}
+	if err := LoadSession(session); err != nil {
+		t.Fatalf("LoadSession: %v", err)
+	}
+
+	// Two reads start from the same position before either is applied
+	pos := session.TailPosition()
+	writeSessionFile(t, path, tailLine2, os.O_APPEND)
+	first, err := ReadTail(pos)
+	if err != nil {
+		t.Fatalf("ReadTail: %v", err)
+	}
+	second, err := ReadTail(pos)
+	if err != nil {
+		t.Fatalf("ReadTail: %v", err)
+	}
+	if first.Since != session.Offset {
+		t.Fatalf("expected first read to start at %d, got %d", session.Offset, first.Since)
+	}
+	session.ApplyTail(first)
+
+	// The second read no longer matches the session and must be dropped
+	if second.Since == session.Offset {
+		t.Errorf("expected second read to be stale, both at offset %d", second.Since)
+	}
+	if len(session.Messages) != 2 {
+		t.Errorf("expected 2 messages, got %d", len(session.Messages))
+	}
+}
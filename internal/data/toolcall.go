@@ -0,0 +1,182 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/natdempk/claude-mri/internal/redact"
+)
+
+// ToolCall links a tool_use block to its matching tool_result, which usually
+// arrives in a later user message
+type ToolCall struct {
+	ID    string
+	Name  string
+	Input string // formatted JSON input
+
+	Use           *ContentBlock
+	UseMessage    *Message
+	Result        *ContentBlock // nil while the call is still running
+	ResultMessage *Message
+
+	StartTime time.Time
+	EndTime   time.Time
+	IsError   bool
+
+	// For Task calls: the subagent session that ran the task
+	AgentID string
+	Agent   *Session
+}
+
+// Done returns true once the result has been seen
+func (c *ToolCall) Done() bool {
+	return c.Result != nil
+}
+
+// Duration returns the time between the tool_use and its result
+func (c *ToolCall) Duration() time.Duration {
+	if !c.Done() || c.StartTime.IsZero() || c.EndTime.IsZero() {
+		return 0
+	}
+	return c.EndTime.Sub(c.StartTime)
+}
+
+// ResultSize returns the size of the result in bytes
+func (c *ToolCall) ResultSize() int {
+	if c.Result == nil {
+		return 0
+	}
+	if c.Result.Lazy != nil {
+		return c.Result.Lazy.Size
+	}
+	return len(c.Result.Result)
+}
+
+// ResultLines returns the number of lines in the result
+func (c *ToolCall) ResultLines() int {
+	if c.Result == nil || c.Result.Result == "" {
+		return 0
+	}
+	if c.Result.Lazy != nil {
+		return c.Result.Lazy.Lines
+	}
+	return strings.Count(strings.TrimRight(c.Result.Result, "\n"), "\n") + 1
+}
+
+// Summary returns a one-line description, e.g. "Bash ls → 12 lines, 340ms".
+// Secrets in the input are masked with r, which may be nil.
+func (c *ToolCall) Summary(r *redact.Redactor) string {
+	s := c.Name
+	if arg := ToolInputSummary(c.Name, c.Use, r); arg != "" {
+		s += " " + arg
+	}
+	if !c.Done() {
+		return s + " → running…"
+	}
+
+	var parts []string
+	if c.IsError {
+		parts = append(parts, "error")
+	}
+	switch n := c.ResultLines(); n {
+	case 0:
+		parts = append(parts, "no output")
+	case 1:
+		parts = append(parts, "1 line")
+	default:
+		parts = append(parts, fmt.Sprintf("%d lines", n))
+	}
+	if d := c.Duration(); d > 0 {
+		parts = append(parts, formatDuration(d))
+	}
+	return s + " → " + strings.Join(parts, ", ")
+}
+
+// ToolInputSummary extracts the most telling input argument of a tool call,
+// e.g. the command for Bash or the file path for Read. Secrets are masked
+// with r, which may be nil, before the argument is shortened, so
+// truncation can't expose part of a secret.
+func ToolInputSummary(name string, use *ContentBlock, r *redact.Redactor) string {
+	if use == nil || use.ToolInput == "" {
+		return ""
+	}
+	var input map[string]interface{}
+	if err := json.Unmarshal([]byte(use.ToolInput), &input); err != nil {
+		return ""
+	}
+	for _, key := range []string{"command", "file_path", "notebook_path", "pattern", "url", "query", "description", "path", "prompt"} {
+		if v, ok := input[key].(string); ok && v != "" {
+			v = r.Redact(v)
+			v = strings.ReplaceAll(v, "\n", " ")
+			return truncateRunes(v, 40)
+		}
+	}
+	return ""
+}
+
+// truncateRunes shortens s to at most max runes, marking the cut with "..."
+func truncateRunes(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	return string([]rune(s)[:max-3]) + "..."
+}
+
+// formatDuration formats a duration compactly: 340ms, 2.1s, 1m05s
+func formatDuration(d time.Duration) string {
+	switch {
+	case d < time.Second:
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	case d < time.Minute:
+		return fmt.Sprintf("%.1fs", d.Seconds())
+	default:
+		return fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+	}
+}
+
+// LinkToolCalls pairs tool_use and tool_result blocks across messages.
+// Existing calls (from earlier messages) can be passed in byID so results
+// arriving in later reads still find their tool_use. Blocks that are
+// already linked are skipped. New calls are returned in the order their
+// tool_use appeared.
+func LinkToolCalls(messages []*Message, byID map[string]*ToolCall) []*ToolCall {
+	var calls []*ToolCall
+	for _, msg := range messages {
+		for i := range msg.Blocks {
+			b := &msg.Blocks[i]
+			if b.Call != nil {
+				continue
+			}
+			switch b.Type {
+			case "tool_use":
+				call := &ToolCall{
+					ID:         b.ToolID,
+					Name:       b.ToolName,
+					Input:      b.ToolInput,
+					Use:        b,
+					UseMessage: msg,
+					StartTime:  msg.Timestamp,
+				}
+				b.Call = call
+				if b.ToolID != "" {
+					byID[b.ToolID] = call
+				}
+				calls = append(calls, call)
+			case "tool_result":
+				call, ok := byID[b.ToolID]
+				if !ok {
+					continue
+				}
+				call.Result = b
+				call.ResultMessage = msg
+				call.EndTime = msg.Timestamp
+				call.IsError = b.IsError
+				b.Call = call
+			}
+		}
+	}
+	return calls
+}
@@ -0,0 +1,57 @@
+package data
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestLinkToolCalls_PairsAcrossMessages(t *testing.T) {
+	use, err := ParseMessageLine([]byte(`{"type":"assistant","uuid":"a1","timestamp":"2025-12-22T22:20:49.806Z","message":{"role":"assistant","content":[{"type":"tool_use","id":"toolu_1","name":"Bash","input":{"command":"ls"}}]}}`))
+	if err != nil {
+		t.Fatalf("parse use: %v", err)
+	}
+	result, err := ParseMessageLine([]byte(`{"type":"user","uuid":"u1","timestamp":"2025-12-22T22:20:50.146Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_1","content":[{"type":"text","text":"a\nb\nc"}]}]}}`))
+	if err != nil {
+		t.Fatalf("parse result: %v", err)
+	}
+
+	byID := make(map[string]*ToolCall)
+	calls := LinkToolCalls([]*Message{use}, byID)
+	if len(calls) != 1 || calls[0].Done() {
+		t.Fatalf("expected one pending call, got %d", len(calls))
+	}
+
+	// Result arrives in a later read
+	if more := LinkToolCalls([]*Message{result}, byID); len(more) != 0 {
+		t.Fatalf("expected no new calls from result, got %d", len(more))
+	}
+	call := calls[0]
+	if !call.Done() {
+		t.Fatalf("expected call to be paired with its result")
+	}
+	if call.Duration() != 340*time.Millisecond {
+		t.Errorf("expected 340ms duration, got %v", call.Duration())
+	}
+	if call.ResultLines() != 3 {
+		t.Errorf("expected 3 result lines, got %d", call.ResultLines())
+	}
+	if got, want := call.Summary(nil), "Bash ls → 3 lines, 340ms"; got != want {
+		t.Errorf("expected summary %q, got %q", want, got)
+	}
+	if result.Blocks[0].Call != call || use.Blocks[0].Call != call {
+		t.Errorf("expected both blocks to reference the call")
+	}
+}
+
+func TestToolInputSummary_TruncatesOnRunes(t *testing.T) {
+	use := &ContentBlock{Type: "tool_use", ToolInput: `{"command": "echo ` + strings.Repeat("é", 40) + `"}`}
+	got := ToolInputSummary("Bash", use, nil)
+	if !utf8.ValidString(got) {
+		t.Fatalf("summary cut inside a rune: %q", got)
+	}
+	if want := "echo " + strings.Repeat("é", 32) + "..."; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
@@ -2,14 +2,20 @@ package data
 
 import (
 	"encoding/json"
+	"os"
 	"time"
 )
 
 // Project represents a Claude project folder
 type Project struct {
-	Name     string
-	Path     string
+	Name     string // display name, unique among scanned projects
+	Path     string // the project folder under the projects directory
+	Cwd      string // real working directory recorded by its sessions
 	Sessions []*Session
+
+	Diagnostics []Diagnostic // problems scanning the project folder
+	Archive     string       // tarball the project was read from, if any
+	Source      string       // label of the root the project was found in
 }
 
 // MostRecentUpdate returns the most recent session update time for this project
@@ -27,10 +33,65 @@ func (p *Project) MostRecentUpdate() time.Time {
 type Session struct {
 	ID        string
 	FilePath  string
-	Messages  []*Message
-	IsAgent   bool      // true for agent-xxx.jsonl files
-	AgentID   string    // populated for agent files
+	Entries   []Entry    // every parsed line, in file order
+	Messages  []*Message // the user/assistant subset of Entries
+	IsAgent   bool       // true for agent-xxx.jsonl files
+	AgentID   string     // populated for agent files
 	UpdatedAt time.Time
+
+	// Subagent links
+	ParentSessionID string     // for agents: sessionId of the spawning session
+	Parent          *Session   // for agents: the spawning session, if found
+	Subagents       []*Session // for main sessions: agents it spawned
+	Prompt          string     // first user prompt
+
+	// Environment recorded on the session's entries (latest values)
+	Cwd       string
+	GitBranch string
+	Version   string
+
+	// Cached metadata from the index; nil when scanned without one
+	Summary *SessionSummary
+
+	// Read position, so watcher events only parse appended lines
+	Offset   int64       // byte offset just past the last consumed line
+	fileInfo os.FileInfo // identity of the file Offset refers to
+
+	// Tool calls paired from tool_use/tool_result blocks, in call order
+	ToolCalls     []*ToolCall
+	toolCallsByID map[string]*ToolCall
+
+	turnsByAPIID map[string]*Message // assistant turns by API message id
+
+	graph *Graph // built lazily from Entries
+
+	Deleted bool // the file was removed or renamed; loaded content is kept
+
+	// Parse problems; unparsable lines are skipped
+	Lines       int // complete lines read so far
+	Diagnostics []Diagnostic
+	malformed   int
+}
+
+// Loaded returns true once the session file has been read at least once
+func (s *Session) Loaded() bool {
+	return s.fileInfo != nil
+}
+
+// ErrorCount returns the number of tool results flagged with is_error
+func (s *Session) ErrorCount() int {
+	if !s.Loaded() && s.Summary != nil {
+		return s.Summary.Errors
+	}
+	count := 0
+	for _, msg := range s.Messages {
+		for _, b := range msg.Blocks {
+			if b.Type == "tool_result" && b.IsError {
+				count++
+			}
+		}
+	}
+	return count
 }
 
 // Message represents a single JSONL entry
@@ -42,15 +103,30 @@ type Message struct {
 	SessionID   string     `json:"sessionId"`
 	AgentID     *string    `json:"agentId"`
 	IsSidechain bool       `json:"isSidechain"`
+	Cwd         string     `json:"cwd"`       // working directory the CLI ran in
+	GitBranch   string     `json:"gitBranch"` // checked-out branch, if any
+	Version     string     `json:"version"`   // Claude Code CLI version
 	Message     RawContent `json:"message"`
+	RequestID   string     `json:"requestId"`
 
 	// Parsed content blocks
 	Blocks []ContentBlock `json:"-"`
 
+	// UUIDs of later streamed chunks merged into this message
+	ChunkUUIDs []string `json:"-"`
+
+	// Agent spawned by the Task call this message returns the result of
+	ResultAgentID string `json:"-"`
+
 	// Parsed metadata (extracted from nested JSON)
 	Model         string `json:"-"` // e.g., "claude-opus-4-5-20251101"
 	StopReason    string `json:"-"` // e.g., "end_turn", "tool_use", "max_tokens"
 	ThinkingLevel string `json:"-"` // e.g., "high", "low", ""
+	Schema        string `json:"-"` // schema adapter that read the entry, e.g. "1.0"
+
+	// Cost and latency recorded by the CLI itself (legacy releases only)
+	CostUSD    float64 `json:"-"`
+	DurationMs int     `json:"-"`
 
 	// Token usage
 	InputTokens      int `json:"-"`
@@ -59,8 +135,20 @@ type Message struct {
 	CacheWriteTokens int `json:"-"`
 }
 
+// HasFailedToolCall returns true if the message holds a tool call that
+// failed, or an unpaired tool result flagged as an error
+func (m *Message) HasFailedToolCall() bool {
+	for _, b := range m.Blocks {
+		if b.Failed() {
+			return true
+		}
+	}
+	return false
+}
+
 // RawContent holds the raw message content from JSON
 type RawContent struct {
+	ID      string          `json:"id"` // API message id, shared by streamed chunks
 	Role    string          `json:"role"`
 	Content json.RawMessage `json:"content"` // can be string or array
 }
@@ -72,8 +160,26 @@ type ContentBlock struct {
 	Thinking  string // for thinking blocks
 	ToolName  string // for tool_use
 	ToolInput string // JSON string of input
-	ToolID    string // tool_use_id
+	ToolID    string // tool_use id, or tool_use_id for tool_result
 	Result    string // for tool_result
+	IsError   bool   // tool_result reported a failure
+
+	Call *ToolCall // paired tool call, for tool_use and tool_result
+
+	Lazy *LazyPayload // set when only a preview of the payload is loaded
+}
+
+// Failed returns true for a tool_use whose result was an error, or for an
+// error tool_result that has no paired tool_use. Paired error results are
+// reported on their tool_use so each failure counts once.
+func (b *ContentBlock) Failed() bool {
+	switch b.Type {
+	case "tool_use":
+		return b.Call != nil && b.Call.IsError
+	case "tool_result":
+		return b.IsError && b.Call == nil
+	}
+	return false
 }
 
 // TreeNode is the interface for displayable tree items
@@ -0,0 +1,162 @@
+package data
+
+import "time"
+
+// TokenUsage counts tokens by kind
+type TokenUsage struct {
+	Input      int `json:"input"`
+	Output     int `json:"output"`
+	CacheRead  int `json:"cacheRead"`
+	CacheWrite int `json:"cacheWrite"`
+}
+
+// Add returns the sum of two usages
+func (u TokenUsage) Add(o TokenUsage) TokenUsage {
+	return TokenUsage{
+		Input:      u.Input + o.Input,
+		Output:     u.Output + o.Output,
+		CacheRead:  u.CacheRead + o.CacheRead,
+		CacheWrite: u.CacheWrite + o.CacheWrite,
+	}
+}
+
+// Total returns all tokens, cached or not
+func (u TokenUsage) Total() int {
+	return u.Input + u.Output + u.CacheRead + u.CacheWrite
+}
+
+// Usage returns the message's token usage
+func (m *Message) Usage() TokenUsage {
+	return TokenUsage{
+		Input:      m.InputTokens,
+		Output:     m.OutputTokens,
+		CacheRead:  m.CacheReadTokens,
+		CacheWrite: m.CacheWriteTokens,
+	}
+}
+
+// UsageBucket is the token usage of one model within one hour
+type UsageBucket struct {
+	Hour    time.Time  `json:"hour"`
+	Model   string     `json:"model"`
+	Usage   TokenUsage `json:"usage"`
+	CostUSD float64    `json:"costUSD,omitempty"` // cost recorded by legacy releases
+}
+
+// addUsage adds usage and recorded cost to the bucket for the timestamp's
+// hour and model. Buckets arrive roughly in time order, so search from the
+// end.
+func addUsage(buckets []UsageBucket, ts time.Time, model string, u TokenUsage, costUSD float64) []UsageBucket {
+	hour := ts.UTC().Truncate(time.Hour)
+	for i := len(buckets) - 1; i >= 0; i-- {
+		if buckets[i].Hour.Equal(hour) && buckets[i].Model == model {
+			buckets[i].Usage = buckets[i].Usage.Add(u)
+			buckets[i].CostUSD += costUSD
+			return buckets
+		}
+	}
+	return append(buckets, UsageBucket{Hour: hour, Model: model, Usage: u, CostUSD: costUSD})
+}
+
+// UsageBuckets returns the session's hourly token usage per model, from its
+// messages once loaded and from the index summary before that
+func (s *Session) UsageBuckets() []UsageBucket {
+	if !s.Loaded() {
+		if s.Summary != nil {
+			return s.Summary.Usage
+		}
+		return nil
+	}
+	var buckets []UsageBucket
+	for _, msg := range s.Messages {
+		if msg.Type != "assistant" {
+			continue
+		}
+		if u := msg.Usage(); u.Total() > 0 || msg.CostUSD > 0 {
+			buckets = addUsage(buckets, msg.Timestamp, msg.Model, u, msg.CostUSD)
+		}
+	}
+	return buckets
+}
+
+// Granularity is the period length of a usage series
+type Granularity int
+
+const (
+	Hourly Granularity = iota
+	Daily
+	Weekly
+)
+
+func (g Granularity) String() string {
+	switch g {
+	case Daily:
+		return "day"
+	case Weekly:
+		return "week"
+	}
+	return "hour"
+}
+
+// Start returns the start of the local period containing t. Weeks start on
+// Monday.
+func (g Granularity) Start(t time.Time) time.Time {
+	t = t.Local()
+	switch g {
+	case Daily:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	case Weekly:
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	}
+	return t.Truncate(time.Hour)
+}
+
+// next returns the start of the period after start
+func (g Granularity) next(start time.Time) time.Time {
+	switch g {
+	case Daily:
+		return start.AddDate(0, 0, 1)
+	case Weekly:
+		return start.AddDate(0, 0, 7)
+	}
+	return start.Add(time.Hour)
+}
+
+// UsagePoint is the token usage within one period of a series
+type UsagePoint struct {
+	Start time.Time
+	Usage TokenUsage
+}
+
+// UsageSeries sums buckets into the n periods ending with the one containing
+// end, oldest first. Periods without usage are included as zero.
+func UsageSeries(buckets []UsageBucket, g Granularity, end time.Time, n int) []UsagePoint {
+	if n <= 0 {
+		return nil
+	}
+	points := make([]UsagePoint, n)
+	start := g.Start(end)
+	for i := n - 1; i >= 0; i-- {
+		points[i].Start = start
+		start = g.Start(start.Add(-time.Minute))
+	}
+	for _, b := range buckets {
+		t := b.Hour
+		if t.Before(points[0].Start) || !t.Before(g.next(points[n-1].Start)) {
+			continue
+		}
+		// Binary search for the last period starting at or before t
+		lo, hi := 0, n-1
+		for lo < hi {
+			mid := (lo + hi + 1) / 2
+			if points[mid].Start.After(t) {
+				hi = mid - 1
+			} else {
+				lo = mid
+			}
+		}
+		points[lo].Usage = points[lo].Usage.Add(b.Usage)
+	}
+	return points
+}
@@ -0,0 +1,34 @@
+package data
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUsageSeries(t *testing.T) {
+	end := time.Date(2025, 1, 8, 15, 30, 0, 0, time.Local) // a Wednesday
+	buckets := []UsageBucket{
+		{Hour: time.Date(2025, 1, 8, 14, 0, 0, 0, time.Local), Model: "m", Usage: TokenUsage{Input: 1}},
+		{Hour: time.Date(2025, 1, 8, 9, 0, 0, 0, time.Local), Model: "m", Usage: TokenUsage{Output: 2}},
+		{Hour: time.Date(2025, 1, 7, 9, 0, 0, 0, time.Local), Model: "m", Usage: TokenUsage{CacheRead: 4}},
+		{Hour: time.Date(2024, 12, 1, 9, 0, 0, 0, time.Local), Model: "m", Usage: TokenUsage{CacheWrite: 8}},
+	}
+
+	daily := UsageSeries(buckets, Daily, end, 3)
+	if len(daily) != 3 || !daily[2].Start.Equal(time.Date(2025, 1, 8, 0, 0, 0, 0, time.Local)) {
+		t.Fatalf("unexpected periods %v", daily)
+	}
+	if daily[2].Usage.Total() != 3 || daily[1].Usage.Total() != 4 || daily[0].Usage.Total() != 0 {
+		t.Errorf("unexpected daily totals %v", daily)
+	}
+
+	weekly := UsageSeries(buckets, Weekly, end, 1)
+	if !weekly[0].Start.Equal(time.Date(2025, 1, 6, 0, 0, 0, 0, time.Local)) || weekly[0].Usage.Total() != 7 {
+		t.Errorf("expected the week from Monday with 7 tokens, got %v", weekly)
+	}
+
+	hourly := UsageSeries(buckets, Hourly, end, 2)
+	if hourly[0].Usage.Input != 1 || hourly[1].Usage.Total() != 0 {
+		t.Errorf("unexpected hourly series %v", hourly)
+	}
+}
@@ -2,156 +2,508 @@ package data
 
 import (
 	"log"
+	"os"
 	"path/filepath"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
 )
 
-// FileEvent represents a file change
+// ChangeKind describes what happened to a file
+type ChangeKind int
+
+const (
+	ChangeAppended ChangeKind = iota
+	ChangeCreated
+	ChangeRemoved
+	ChangeRenamed
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case ChangeCreated:
+		return "created"
+	case ChangeRemoved:
+		return "removed"
+	case ChangeRenamed:
+		return "renamed"
+	}
+	return "appended"
+}
+
+// then folds a later change to the same file into k. A file created and
+// then written is still new; anything else takes the later change.
+func (k ChangeKind) then(later ChangeKind) ChangeKind {
+	if k == ChangeCreated && later == ChangeAppended {
+		return ChangeCreated
+	}
+	return later
+}
+
+// FileEvent represents a change to one session file, or to a project
+// directory when IsProject is set. Renamed means the file moved away from
+// Path; its new name, if still watched, arrives as a separate Created event.
 type FileEvent struct {
-	Path    string
-	Project string
-	IsNew   bool
+	Path      string
+	Project   string
+	SessionID string
+	// ParentSessionID is the session whose subagents directory holds the
+	// file, if any
+	ParentSessionID string
+	Kind            ChangeKind
+	IsProject       bool
 }
 
-// Watcher watches the Claude projects directory for changes
+// Watcher watches one or more Claude projects directories for changes.
+// Changes are debounced and delivered on Events as batches with one event
+// per file; while a batch waits to be received, later changes are folded
+// into it rather than dropped.
 type Watcher struct {
-	watcher  *fsnotify.Watcher
-	basePath string
-	Events   chan FileEvent
+	backend  Backend
+	polling  bool
+	roots    []string
+	watched  map[string]bool // roots, project, session and subagents directories being watched
+	projects map[string]bool // watched project directories
+	running  bool
+	Events   chan []FileEvent
 	Errors   chan error
 	done     chan struct{}
 
-	// Debouncing
 	debounceDelay time.Duration
-	pending       map[string]FileEvent
-	pendingMu     sync.Mutex
-	debounceTimer *time.Timer
+	pending       map[string]*FileEvent
+	order         []string // pending paths, first seen first
+}
+
+// Backend delivers raw filesystem events for the directories added to it.
+// Events for entries of a watched directory use fsnotify's Create, Write,
+// Remove and Rename ops.
+type Backend interface {
+	Add(path string) error
+	Remove(path string) error
+	Events() <-chan fsnotify.Event
+	Errors() <-chan error
+	Close() error
 }
 
-// NewWatcher creates a new file watcher
-func NewWatcher(basePath string) (*Watcher, error) {
-	w, err := fsnotify.NewWatcher()
+// fsnotifyBackend is the native backend (inotify, kqueue, ...)
+type fsnotifyBackend struct {
+	w *fsnotify.Watcher
+}
+
+func (b fsnotifyBackend) Add(path string) error         { return b.w.Add(path) }
+func (b fsnotifyBackend) Remove(path string) error      { return b.w.Remove(path) }
+func (b fsnotifyBackend) Events() <-chan fsnotify.Event { return b.w.Events }
+func (b fsnotifyBackend) Errors() <-chan error          { return b.w.Errors }
+func (b fsnotifyBackend) Close() error                  { return b.w.Close() }
+
+// NewWatcher creates a file watcher for the given projects directories. It
+// uses native filesystem events, falling back to polling every
+// PollInterval when they are unavailable or run out.
+func NewWatcher(roots ...string) (*Watcher, error) {
+	if ForcePolling {
+		return NewWatcherWithBackend(NewPollBackend(PollInterval), roots...), nil
+	}
+	fw, err := fsnotify.NewWatcher()
 	if err != nil {
-		return nil, err
+		log.Printf("Warning: %v; falling back to polling", err)
+		return NewWatcherWithBackend(NewPollBackend(PollInterval), roots...), nil
 	}
+	return NewWatcherWithBackend(fsnotifyBackend{fw}, roots...), nil
+}
 
+// NewWatcherWithBackend creates a file watcher using the given backend,
+// e.g. a PollBackend for filesystems that deliver no events (NFS, sshfs,
+// some container volumes)
+func NewWatcherWithBackend(backend Backend, roots ...string) *Watcher {
+	_, polling := backend.(*PollBackend)
 	return &Watcher{
-		watcher:       w,
-		basePath:      basePath,
-		Events:        make(chan FileEvent, 100),
+		backend:       backend,
+		polling:       polling,
+		roots:         roots,
+		watched:       make(map[string]bool),
+		projects:      make(map[string]bool),
+		Events:        make(chan []FileEvent),
 		Errors:        make(chan error, 10),
 		done:          make(chan struct{}),
 		debounceDelay: 500 * time.Millisecond,
-		pending:       make(map[string]FileEvent),
-	}, nil
+		pending:       make(map[string]*FileEvent),
+	}
+}
+
+// Polling reports whether the watcher polls instead of receiving events
+func (w *Watcher) Polling() bool {
+	return w.polling
 }
 
 // Start begins watching for file changes
 func (w *Watcher) Start() error {
-	// Watch base path
-	if err := w.watcher.Add(w.basePath); err != nil {
-		return err
-	}
+	for _, root := range w.roots {
+		// Archives are read-only snapshots
+		if IsArchive(root) {
+			continue
+		}
+		// A missing root shouldn't stop the others being watched
+		if err := w.add(root); err != nil {
+			log.Printf("Warning: could not watch %s: %v", root, err)
+			continue
+		}
 
-	// Watch all project directories
-	projects, err := ScanProjects(w.basePath)
-	if err != nil {
-		return err
-	}
-	for _, p := range projects {
-		if err := w.watcher.Add(p.Path); err != nil {
-			log.Printf("Warning: could not watch %s: %v", p.Path, err)
+		// Watch all project directories. Only their names are needed, so
+		// this lists the root rather than scanning the sessions in it.
+		entries, err := os.ReadDir(root)
+		if err != nil {
+			log.Printf("Warning: could not list %s: %v", root, err)
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				w.watchProject(filepath.Join(root, entry.Name()))
+			}
 		}
 	}
 
+	w.running = true
 	go w.run()
 	return nil
 }
 
+// add watches a directory, switching to polling if the native backend
+// can't add any more watches
+func (w *Watcher) add(path string) error {
+	if w.watched[path] {
+		return nil
+	}
+	err := w.backend.Add(path)
+	if err != nil && !w.polling && !os.IsNotExist(err) {
+		log.Printf("Warning: could not watch %s: %v; falling back to polling", path, err)
+		w.fallBackToPolling()
+		err = w.backend.Add(path)
+	}
+	if err == nil {
+		w.watched[path] = true
+	}
+	return err
+}
+
+// fallBackToPolling replaces the native backend with a PollBackend watching
+// the same directories. It runs before the event loop starts or on it.
+func (w *Watcher) fallBackToPolling() {
+	poll := NewPollBackend(PollInterval)
+	for path := range w.watched {
+		if err := poll.Add(path); err != nil {
+			delete(w.watched, path)
+		}
+	}
+	w.backend.Close()
+	w.backend = poll
+	w.polling = true
+}
+
 func (w *Watcher) run() {
+	defer func() { w.backend.Close() }()
+
+	// Changes are collected for debounceDelay after the first one, so a
+	// session written continuously still produces a batch every interval
+	timer := time.NewTimer(w.debounceDelay)
+	timer.Stop()
+	waiting, ready := false, false
 	for {
+		var out chan []FileEvent
+		var batch []FileEvent
+		if ready && len(w.order) > 0 {
+			out = w.Events
+			batch = w.batch()
+		}
+
 		select {
-		case event, ok := <-w.watcher.Events:
+		case event, ok := <-w.backend.Events():
 			if !ok {
 				return
 			}
-
-			// Only care about writes and creates to .jsonl files
-			if !strings.HasSuffix(event.Name, ".jsonl") {
-				continue
+			if w.handle(event) && !waiting && !ready {
+				timer.Reset(w.debounceDelay)
+				waiting = true
 			}
 
-			if event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
-				continue
-			}
+		case <-timer.C:
+			waiting, ready = false, true
 
-			// Extract project name from path
-			rel, _ := filepath.Rel(w.basePath, event.Name)
-			parts := strings.Split(rel, string(filepath.Separator))
-			project := ""
-			if len(parts) > 0 {
-				project = parts[0]
-			}
+		case out <- batch:
+			w.pending = make(map[string]*FileEvent)
+			w.order = nil
+			ready = false
 
-			// Debounce: add to pending and reset timer
-			w.pendingMu.Lock()
-			w.pending[event.Name] = FileEvent{
-				Path:    event.Name,
-				Project: project,
-				IsNew:   event.Op&fsnotify.Create != 0,
-			}
-
-			// Reset or start debounce timer
-			if w.debounceTimer != nil {
-				w.debounceTimer.Stop()
-			}
-			w.debounceTimer = time.AfterFunc(w.debounceDelay, w.flushPending)
-			w.pendingMu.Unlock()
-
-		case err, ok := <-w.watcher.Errors:
+		case err, ok := <-w.backend.Errors():
 			if !ok {
 				return
 			}
-			w.Errors <- err
+			select {
+			case w.Errors <- err:
+			case <-w.done:
+				return
+			}
 
 		case <-w.done:
+			timer.Stop()
 			return
 		}
 	}
 }
 
-// flushPending sends all pending events as a single event
-func (w *Watcher) flushPending() {
-	w.pendingMu.Lock()
-	defer w.pendingMu.Unlock()
+// handle turns an fsnotify event into a pending change, returning true if
+// one was queued
+func (w *Watcher) handle(event fsnotify.Event) bool {
+	if w.isProjectDir(event.Name) {
+		return w.handleProjectEvent(event)
+	}
+
+	// Only care about session files, plain or compressed, directly in a
+	// project or in a session's subagents directory. Other entries of a
+	// session directory may be the subagents directory itself.
+	parts := w.pathParts(event.Name)
+	isSession := isSessionFile(filepath.Base(event.Name))
+	switch {
+	case len(parts) == 2 && !isSession:
+		return w.handleSessionDirEvent(event, false)
+	case len(parts) == 3 && parts[2] == subagentsDir:
+		return w.handleSessionDirEvent(event, true)
+	case !isSession:
+		return false
+	case len(parts) != 2 && !(len(parts) == 4 && parts[2] == subagentsDir):
+		return false
+	}
+	var kind ChangeKind
+	switch {
+	case event.Op&fsnotify.Create != 0:
+		kind = ChangeCreated
+	case event.Op&fsnotify.Write != 0:
+		kind = ChangeAppended
+	case event.Op&fsnotify.Remove != 0:
+		kind = ChangeRemoved
+	case event.Op&fsnotify.Rename != 0:
+		kind = ChangeRenamed
+	default:
+		return false
+	}
+	w.queue(w.fileEvent(event.Name, kind))
+	return true
+}
+
+// fileEvent describes a change to the session or agent file at path
+func (w *Watcher) fileEvent(path string, kind ChangeKind) FileEvent {
+	evt := FileEvent{
+		Path:      path,
+		Project:   w.projectOf(path),
+		SessionID: sessionIDOf(path),
+		Kind:      kind,
+	}
+	if parts := w.pathParts(path); len(parts) == 4 && parts[2] == subagentsDir {
+		evt.ParentSessionID = parts[1]
+	}
+	return evt
+}
+
+// queue adds a change to the pending batch, folding it into any earlier
+// change to the same path
+func (w *Watcher) queue(evt FileEvent) {
+	if prev, ok := w.pending[evt.Path]; ok {
+		prev.Kind = prev.Kind.then(evt.Kind)
+		return
+	}
+	w.pending[evt.Path] = &evt
+	w.order = append(w.order, evt.Path)
+}
+
+// batch returns the pending changes in the order they were first seen
+func (w *Watcher) batch() []FileEvent {
+	events := make([]FileEvent, len(w.order))
+	for i, path := range w.order {
+		events[i] = *w.pending[path]
+	}
+	return events
+}
 
-	if len(w.pending) == 0 {
+// watchProject adds a watch for a project directory and the session
+// directories in it
+func (w *Watcher) watchProject(path string) {
+	if err := w.add(path); err != nil {
+		log.Printf("Warning: could not watch %s: %v", path, err)
 		return
 	}
+	w.projects[path] = true
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			w.watchSessionDir(filepath.Join(path, entry.Name()), false)
+		}
+	}
+}
 
-	// Send just one event (the most recent one) to trigger a refresh
-	var lastEvent FileEvent
-	for _, evt := range w.pending {
-		lastEvent = evt
+// subagentsDir is the directory under <project>/<session-id>/ that newer
+// releases keep subagent transcripts in
+const subagentsDir = "subagents"
+
+// watchSessionDir watches a session's directory so its subagents directory
+// is noticed when created, and that directory if it already exists. With
+// announce set, agent files already there are queued as created, returning
+// true if there were any.
+func (w *Watcher) watchSessionDir(dir string, announce bool) bool {
+	if err := w.add(dir); err != nil {
+		log.Printf("Warning: could not watch %s: %v", dir, err)
+		return false
+	}
+	sub := filepath.Join(dir, subagentsDir)
+	if info, err := os.Stat(sub); err == nil && info.IsDir() {
+		return w.watchSubagents(sub, announce)
 	}
+	return false
+}
 
-	// Clear pending
-	w.pending = make(map[string]FileEvent)
+// watchSubagents watches a session's subagents directory. Agent files
+// written before the watch was added are queued as created when announce
+// is set, returning true if there were any.
+func (w *Watcher) watchSubagents(dir string, announce bool) bool {
+	if err := w.add(dir); err != nil {
+		log.Printf("Warning: could not watch %s: %v", dir, err)
+		return false
+	}
+	if !announce {
+		return false
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	queued := false
+	for _, entry := range entries {
+		if !entry.IsDir() && agentFileRe.MatchString(entry.Name()) {
+			w.queue(w.fileEvent(filepath.Join(dir, entry.Name()), ChangeCreated))
+			queued = true
+		}
+	}
+	return queued
+}
 
-	// Send the event
-	select {
-	case w.Events <- lastEvent:
+// handleSessionDirEvent watches session directories, or subagents
+// directories when subagents is set, created after Start and forgets
+// removed ones. Only agent files found in a new subagents directory are
+// queued.
+func (w *Watcher) handleSessionDirEvent(event fsnotify.Event, subagents bool) bool {
+	switch {
+	case event.Op&fsnotify.Create != 0:
+		info, err := os.Stat(event.Name)
+		if err != nil || !info.IsDir() {
+			return false
+		}
+		if subagents {
+			return w.watchSubagents(event.Name, true)
+		}
+		return w.watchSessionDir(event.Name, true)
+	case event.Op&(fsnotify.Remove|fsnotify.Rename) != 0:
+		w.unwatch(event.Name)
+	}
+	return false
+}
+
+// unwatch forgets a removed or renamed directory and every watched
+// directory below it. fsnotify drops watches of deleted directories
+// itself, but not of renamed ones.
+func (w *Watcher) unwatch(dir string) {
+	prefix := dir + string(filepath.Separator)
+	for path := range w.watched {
+		if path == dir || strings.HasPrefix(path, prefix) {
+			w.backend.Remove(path)
+			delete(w.watched, path)
+			delete(w.projects, path)
+		}
+	}
+}
+
+// isProjectDir reports whether path is directly inside one of the roots.
+// Tarballs dropped into a root count too, so they show up after a rescan.
+func (w *Watcher) isProjectDir(path string) bool {
+	dir := filepath.Dir(path)
+	for _, root := range w.roots {
+		if filepath.Clean(root) == dir {
+			return true
+		}
+	}
+	return false
+}
+
+// handleProjectEvent watches project directories created after Start and
+// forgets removed ones, queueing a project-level event for either
+func (w *Watcher) handleProjectEvent(event fsnotify.Event) bool {
+	evt := FileEvent{
+		Path:      event.Name,
+		Project:   filepath.Base(event.Name),
+		IsProject: true,
+	}
+	switch {
+	case event.Op&fsnotify.Create != 0:
+		if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+			w.watchProject(event.Name)
+		} else if !IsArchive(event.Name) {
+			return false
+		}
+		evt.Kind = ChangeCreated
+	case event.Op&(fsnotify.Remove|fsnotify.Rename) != 0:
+		if w.projects[event.Name] {
+			w.unwatch(event.Name)
+		} else if !IsArchive(event.Name) {
+			return false
+		}
+		evt.Kind = ChangeRemoved
+		if event.Op&fsnotify.Rename != 0 {
+			evt.Kind = ChangeRenamed
+		}
 	default:
-		// Channel full, skip
+		return false
+	}
+	w.queue(evt)
+	return true
+}
+
+// projectOf returns the project folder name of a path under one of the roots
+func (w *Watcher) projectOf(path string) string {
+	if parts := w.pathParts(path); len(parts) > 0 {
+		return parts[0]
 	}
+	return ""
 }
 
-// Stop stops the watcher
+// pathParts splits a path under one of the roots into its components below
+// the root: project, session file or directory, subagents, agent file
+func (w *Watcher) pathParts(path string) []string {
+	for _, root := range w.roots {
+		rel, err := filepath.Rel(root, path)
+		if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		return strings.Split(rel, string(filepath.Separator))
+	}
+	return nil
+}
+
+// sessionIDOf returns the session ID of a session or agent file name
+func sessionIDOf(path string) string {
+	name := filepath.Base(path)
+	if m := agentFileRe.FindStringSubmatch(name); m != nil {
+		return m[1]
+	}
+	return TrimSessionExt(name)
+}
+
+// Stop stops the watcher. The event loop owns the backend once started,
+// since it may swap it for polling, so it closes it on the way out.
 func (w *Watcher) Stop() {
 	close(w.done)
-	w.watcher.Close()
+	if !w.running {
+		w.backend.Close()
+	}
 }
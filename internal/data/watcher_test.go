@@ -0,0 +1,251 @@
+package data
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// nextBatch waits for the watcher's next batch of events
+func nextBatch(t *testing.T, w *Watcher) []FileEvent {
+	t.Helper()
+	select {
+	case events := <-w.Events:
+		return events
+	case err := <-w.Errors:
+		t.Fatalf("watcher error: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for watcher event")
+	}
+	return nil
+}
+
+// nextEvent waits for a batch holding a single event
+func nextEvent(t *testing.T, w *Watcher) FileEvent {
+	t.Helper()
+	events := nextBatch(t, w)
+	if len(events) != 1 {
+		t.Fatalf("expected one event, got %+v", events)
+	}
+	return events[0]
+}
+
+// backends are the watcher backends each watcher test runs against
+var backends = map[string]func() Backend{
+	"native": func() Backend {
+		fw, err := fsnotify.NewWatcher()
+		if err != nil {
+			panic(err)
+		}
+		return fsnotifyBackend{fw}
+	},
+	"poll": func() Backend { return NewPollBackend(10 * time.Millisecond) },
+}
+
+func startWatcher(t *testing.T, backend Backend, root string, delay time.Duration) *Watcher {
+	t.Helper()
+	w := NewWatcherWithBackend(backend, root)
+	w.debounceDelay = delay
+	if err := w.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(w.Stop)
+	return w
+}
+
+func TestWatcher_NewAndRemovedProjects(t *testing.T) {
+	for name, backend := range backends {
+		t.Run(name, func(t *testing.T) { testNewAndRemovedProjects(t, backend()) })
+	}
+}
+
+func testNewAndRemovedProjects(t *testing.T, backend Backend) {
+	root := t.TempDir()
+	w := startWatcher(t, backend, root, 20*time.Millisecond)
+
+	proj := filepath.Join(root, "-src-new-app")
+	if err := os.Mkdir(proj, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	evt := nextEvent(t, w)
+	if !evt.IsProject || evt.Kind != ChangeCreated || evt.Project != "-src-new-app" {
+		t.Fatalf("expected new project event, got %+v", evt)
+	}
+
+	// Sessions in the new project are watched
+	session := filepath.Join(proj, "0b9a7c3e-1f2d-4e5a-8b6c-7d8e9f0a1b2c.jsonl")
+	writeSessionFile(t, session, tailLine1, os.O_TRUNC)
+	evt = nextEvent(t, w)
+	if evt.IsProject || evt.Path != session || evt.Project != "-src-new-app" || evt.SessionID != "0b9a7c3e-1f2d-4e5a-8b6c-7d8e9f0a1b2c" || evt.Kind != ChangeCreated {
+		t.Fatalf("expected session event, got %+v", evt)
+	}
+
+	if err := os.RemoveAll(proj); err != nil {
+		t.Fatal(err)
+	}
+	events := nextBatch(t, w)
+	last := events[len(events)-1]
+	if !last.IsProject || last.Kind != ChangeRemoved {
+		t.Fatalf("expected removed project event, got %+v", events)
+	}
+	if w.projects[proj] {
+		t.Error("expected removed project to be unwatched")
+	}
+}
+
+func TestWatcher_BatchesPerFile(t *testing.T) {
+	for name, backend := range backends {
+		t.Run(name, func(t *testing.T) { testBatchesPerFile(t, backend()) })
+	}
+}
+
+func testBatchesPerFile(t *testing.T, backend Backend) {
+	root := t.TempDir()
+	proj := filepath.Join(root, "-src-app")
+	if err := os.Mkdir(proj, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	a := filepath.Join(proj, "11111111-1111-4111-8111-111111111111.jsonl")
+	b := filepath.Join(proj, "agent-abc123.jsonl")
+	writeSessionFile(t, a, tailLine1, os.O_TRUNC)
+
+	w := startWatcher(t, backend, root, 50*time.Millisecond)
+
+	// Changes keep accumulating while nobody receives the batch
+	writeSessionFile(t, a, tailLine2, os.O_APPEND)
+	writeSessionFile(t, b, tailLine1, os.O_TRUNC)
+	writeSessionFile(t, b, tailLine2, os.O_APPEND)
+	time.Sleep(200 * time.Millisecond)
+	if err := os.Remove(a); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	got := make(map[string]FileEvent)
+	for len(got) < 2 {
+		for _, evt := range nextBatch(t, w) {
+			got[evt.Path] = evt
+		}
+	}
+	if evt := got[a]; evt.Kind != ChangeRemoved || evt.SessionID != "11111111-1111-4111-8111-111111111111" {
+		t.Errorf("expected %s removed, got %+v", a, evt)
+	}
+	if evt := got[b]; evt.Kind != ChangeCreated || evt.SessionID != "abc123" || evt.Project != "-src-app" {
+		t.Errorf("expected %s created, got %+v", b, evt)
+	}
+}
+
+// limitedBackend fails to add watches like inotify past its watch limit
+type limitedBackend struct {
+	fsnotifyBackend
+}
+
+func (limitedBackend) Add(string) error { return syscall.ENOSPC }
+
+func TestWatcher_FallsBackToPolling(t *testing.T) {
+	defer func(old time.Duration) { PollInterval = old }(PollInterval)
+	PollInterval = 10 * time.Millisecond
+
+	root := t.TempDir()
+	proj := filepath.Join(root, "-src-app")
+	if err := os.Mkdir(proj, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	w := startWatcher(t, limitedBackend{backends["native"]().(fsnotifyBackend)}, root, 20*time.Millisecond)
+	if !w.Polling() {
+		t.Fatal("expected fallback to polling")
+	}
+
+	session := filepath.Join(proj, "agent-abc123.jsonl")
+	writeSessionFile(t, session, tailLine1, os.O_TRUNC)
+	if evt := nextEvent(t, w); evt.Path != session || evt.Kind != ChangeCreated {
+		t.Errorf("expected session created while polling, got %+v", evt)
+	}
+}
+
+func TestWatcher_Subagents(t *testing.T) {
+	for name, backend := range backends {
+		t.Run(name, func(t *testing.T) { testSubagents(t, backend()) })
+	}
+}
+
+func testSubagents(t *testing.T, backend Backend) {
+	root := t.TempDir()
+	parent := "11111111-1111-4111-8111-111111111111"
+	subagents := filepath.Join(root, "-src-app", parent, "subagents")
+	if err := os.MkdirAll(subagents, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	agent := filepath.Join(subagents, "agent-abc123.jsonl")
+	writeSessionFile(t, agent, tailLine1, os.O_TRUNC)
+
+	w := startWatcher(t, backend, root, 20*time.Millisecond)
+
+	// Subagents present at startup are watched
+	writeSessionFile(t, agent, tailLine2, os.O_APPEND)
+	evt := nextEvent(t, w)
+	if evt.Path != agent || evt.Kind != ChangeAppended || evt.Project != "-src-app" ||
+		evt.SessionID != "abc123" || evt.ParentSessionID != parent {
+		t.Fatalf("expected subagent append, got %+v", evt)
+	}
+
+	// So are those of sessions that spawn their first agent later
+	later := "22222222-2222-4222-8222-222222222222"
+	subagents = filepath.Join(root, "-src-app", later, "subagents")
+	if err := os.MkdirAll(subagents, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	agent = filepath.Join(subagents, "agent-def456.jsonl")
+	writeSessionFile(t, agent, tailLine1, os.O_TRUNC)
+	for {
+		var found *FileEvent
+		for _, evt := range nextBatch(t, w) {
+			if evt.Path == agent {
+				found = &evt
+			}
+		}
+		if found != nil {
+			if found.Kind != ChangeCreated || found.SessionID != "def456" || found.ParentSessionID != later {
+				t.Errorf("expected new subagent created, got %+v", *found)
+			}
+			break
+		}
+	}
+}
+
+func TestWatcher_CompressedSessions(t *testing.T) {
+	for name, backend := range backends {
+		t.Run(name, func(t *testing.T) {
+			root := t.TempDir()
+			proj := filepath.Join(root, "-src-app")
+			if err := os.Mkdir(proj, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			w := startWatcher(t, backend(), root, 20*time.Millisecond)
+
+			id := "0b9a7c3e-1f2d-4e5a-8b6c-7d8e9f0a1b2c"
+			session := filepath.Join(proj, id+".jsonl.gz")
+			if err := os.WriteFile(session, gzipBytes(t, []byte(tailLine1)), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			for {
+				var found *FileEvent
+				for _, evt := range nextBatch(t, w) {
+					if evt.Path == session {
+						found = &evt
+					}
+				}
+				if found != nil {
+					if found.SessionID != id || found.Project != "-src-app" {
+						t.Errorf("expected the compressed session reported, got %+v", *found)
+					}
+					break
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/natdempk/claude-mri/internal/data"
+)
+
+// activityRefresh is how often live indicators are re-evaluated, so quiet
+// sessions go idle without waiting for another file change
+const activityRefresh = 5 * time.Second
+
+// activityTickMsg triggers a redraw of the live indicators
+type activityTickMsg time.Time
+
+func activityTick() tea.Cmd {
+	return tea.Tick(activityRefresh, func(t time.Time) tea.Msg {
+		return activityTickMsg(t)
+	})
+}
+
+// NodeActivity reports whether a project or session node is live. ok is
+// false for nodes that don't show an indicator.
+func NodeActivity(node *TreeNode, now time.Time) (active, ok bool) {
+	switch {
+	case node.Type == NodeSession && node.Session != nil:
+		return node.Session.IsActive(now), true
+	case node.Type == NodeProject && node.Project != nil:
+		sessions, agents := node.Project.ActiveSessions(now)
+		return sessions+agents > 0, true
+	}
+	return false, false
+}
+
+// ActiveCounts counts live sessions and subagents across all projects
+func (m Model) ActiveCounts(now time.Time) (sessions, agents int) {
+	for _, p := range m.Projects {
+		s, a := p.ActiveSessions(now)
+		sessions += s
+		agents += a
+	}
+	return sessions, agents
+}
+
+// noteWrite records a watcher event against a session so it shows as live
+// before its new lines are parsed
+func noteWrite(session *data.Session, at time.Time) {
+	if at.After(session.UpdatedAt) {
+		session.UpdatedAt = at
+	}
+}
@@ -0,0 +1,127 @@
+package model
+
+import "github.com/natdempk/claude-mri/internal/data"
+
+// AgentStep records where we were before stepping into a subagent
+type AgentStep struct {
+	Session      *data.Session
+	DetailScroll int
+}
+
+// stepIntoAgent opens the subagent spawned by the Task call nearest the
+// detail scroll position, searching forward first
+func (m *Model) stepIntoAgent() {
+	if m.Selected == nil || m.Selected.Session == nil {
+		return
+	}
+	entries := m.getSelectedEntries()
+	current, _ := m.entryAtScroll(entries)
+
+	agent := taskAgentIn(entries[min(current, len(entries)):])
+	if agent == nil {
+		agent = lastTaskAgentIn(entries[:min(current, len(entries))])
+	}
+	if agent == nil {
+		return
+	}
+
+	step := AgentStep{Session: m.Selected.Session, DetailScroll: m.DetailScroll}
+	if m.revealSession(agent) {
+		m.AgentTrail = append(m.AgentTrail, step)
+		m.DetailScroll = 0
+	}
+}
+
+// stepOutOfAgent returns to the session we stepped in from, or to the
+// spawning session when the agent was opened from the tree
+func (m *Model) stepOutOfAgent() {
+	if n := len(m.AgentTrail); n > 0 {
+		step := m.AgentTrail[n-1]
+		if m.revealSession(step.Session) {
+			m.AgentTrail = m.AgentTrail[:n-1]
+			m.DetailScroll = step.DetailScroll
+		}
+		return
+	}
+	if m.Selected != nil && m.Selected.Session != nil && m.Selected.Session.Parent != nil {
+		if m.revealSession(m.Selected.Session.Parent) {
+			m.DetailScroll = 0
+		}
+	}
+}
+
+// taskAgentIn returns the first subagent spawned by a Task call in entries
+func taskAgentIn(entries []data.Entry) *data.Session {
+	for _, e := range entries {
+		if agent := messageTaskAgent(e); agent != nil {
+			return agent
+		}
+	}
+	return nil
+}
+
+// lastTaskAgentIn returns the last subagent spawned by a Task call in entries
+func lastTaskAgentIn(entries []data.Entry) *data.Session {
+	for i := len(entries) - 1; i >= 0; i-- {
+		if agent := messageTaskAgent(entries[i]); agent != nil {
+			return agent
+		}
+	}
+	return nil
+}
+
+func messageTaskAgent(e data.Entry) *data.Session {
+	msg, ok := e.(*data.Message)
+	if !ok {
+		return nil
+	}
+	for _, b := range msg.Blocks {
+		if b.Type == "tool_use" && b.Call != nil && b.Call.Agent != nil {
+			return b.Call.Agent
+		}
+	}
+	return nil
+}
+
+// revealSession expands the tree down to a session's node, selects it and
+// shows it in the detail pane
+func (m *Model) revealSession(s *data.Session) bool {
+	var path []*TreeNode
+	for _, node := range m.Tree {
+		if path = findSessionPath(node, s); path != nil {
+			break
+		}
+	}
+	if path == nil {
+		return false
+	}
+	for _, node := range path[:len(path)-1] {
+		node.Expanded = true
+	}
+	m.flattenTree()
+	target := path[len(path)-1]
+	for i, node := range m.FlatNodes {
+		if node == target {
+			m.Cursor = i
+			m.Selected = node
+			break
+		}
+	}
+	m.ensureCursorVisible()
+	m.loadSelectedSession()
+	m.Focus = DetailPane
+	return true
+}
+
+// findSessionPath returns the nodes from node down to the session's node
+func findSessionPath(node *TreeNode, s *data.Session) []*TreeNode {
+	if node.Type == NodeSession && node.Session == s {
+		return []*TreeNode{node}
+	}
+	for _, child := range node.Children {
+		if path := findSessionPath(child, s); path != nil {
+			return append([]*TreeNode{node}, path...)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package model
+
+import (
+	"sort"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/natdempk/claude-mri/internal/data"
+)
+
+// Analytics is the state of the token usage screen
+type Analytics struct {
+	Open        bool
+	Granularity data.Granularity
+	Model       string // "" for all models
+	Project     string // project path, "" for all projects
+}
+
+// AnalyticsModels returns the models with recorded usage, sorted
+func (m Model) AnalyticsModels() []string {
+	seen := make(map[string]bool)
+	for _, p := range m.Projects {
+		for _, s := range p.Sessions {
+			for _, b := range s.UsageBuckets() {
+				if b.Model != "" {
+					seen[b.Model] = true
+				}
+			}
+		}
+	}
+	models := make([]string, 0, len(seen))
+	for model := range seen {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+	return models
+}
+
+// AnalyticsProject returns the project the screen is filtered to, if any
+func (m Model) AnalyticsProject() *data.Project {
+	for _, p := range m.Projects {
+		if p.Path == m.Analytics.Project {
+			return p
+		}
+	}
+	return nil
+}
+
+// AnalyticsSeries returns n periods of token usage ending now, filtered by
+// the selected model and project
+func (m Model) AnalyticsSeries(n int) []data.UsagePoint {
+	var buckets []data.UsageBucket
+	for _, p := range m.Projects {
+		if m.Analytics.Project != "" && p.Path != m.Analytics.Project {
+			continue
+		}
+		for _, s := range p.Sessions {
+			for _, b := range s.UsageBuckets() {
+				if m.Analytics.Model == "" || b.Model == m.Analytics.Model {
+					buckets = append(buckets, b)
+				}
+			}
+		}
+	}
+	return data.UsageSeries(buckets, m.Analytics.Granularity, time.Now(), n)
+}
+
+func (m Model) handleAnalyticsKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "t", "esc":
+		m.Analytics.Open = false
+
+	case "g":
+		m.Analytics.Granularity = (m.Analytics.Granularity + 1) % 3
+
+	case "m":
+		m.Analytics.Model = cycleString(m.AnalyticsModels(), m.Analytics.Model)
+
+	case "p":
+		paths := make([]string, len(m.Projects))
+		for i, p := range m.Projects {
+			paths[i] = p.Path
+		}
+		m.Analytics.Project = cycleString(paths, m.Analytics.Project)
+	}
+	return m, nil
+}
+
+// cycleString returns the option after current, with "" (all) before the
+// first and after the last
+func cycleString(options []string, current string) string {
+	if current == "" {
+		if len(options) == 0 {
+			return ""
+		}
+		return options[0]
+	}
+	for i, o := range options {
+		if o == current && i+1 < len(options) {
+			return options[i+1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,109 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/natdempk/claude-mri/internal/data"
+)
+
+// BranchMarker is a divider row inserted into the detail pane when
+// comparing branches
+type BranchMarker struct {
+	ID    string
+	Title string
+}
+
+func (b *BranchMarker) EntryType() string    { return "branch" }
+func (b *BranchMarker) EntryID() string      { return b.ID }
+func (b *BranchMarker) EntryTime() time.Time { return time.Time{} }
+func (b *BranchMarker) Describe() string     { return b.Title }
+func (b *BranchMarker) Details() string      { return "" }
+
+// selectedBranches returns the branches of the selected session and the
+// index of the branch being viewed. The branch is remembered by its leaf,
+// so it stays selected as new branches appear; if the leaf has since been
+// continued, the branch continuing it is the one viewed.
+func (m Model) selectedBranches() ([]*data.Branch, int) {
+	if m.Selected == nil || m.Selected.Type != NodeSession || m.Selected.Session == nil {
+		return nil, 0
+	}
+	branches := m.Selected.Session.Graph().Branches()
+	if m.Branch == "" || m.BranchSession != m.Selected.Session.FilePath {
+		return branches, 0
+	}
+	for i, b := range branches {
+		if b.Leaf.UUID == m.Branch {
+			return branches, i
+		}
+	}
+	for i, b := range branches {
+		for n := b.Leaf.Parent; n != nil; n = n.Parent {
+			if n.UUID == m.Branch {
+				return branches, i
+			}
+		}
+	}
+	return branches, 0
+}
+
+// DetailEntries returns the entries shown in the detail pane: the active
+// path by default, the selected branch, or both when comparing
+func (m Model) DetailEntries() []data.Entry {
+	if m.Selected == nil || m.Selected.Type != NodeSession || m.Selected.Session == nil {
+		return nil
+	}
+	graph := m.Selected.Session.Graph()
+	branches, idx := m.selectedBranches()
+	if len(branches) == 0 {
+		return graph.ActivePath()
+	}
+	branch := branches[idx]
+	if !m.CompareBranch || branch.Active {
+		return graph.Path(branch)
+	}
+
+	// Compare: shared history, then each side of the fork
+	active := graph.Path(branches[0])
+	other := graph.Path(branch)
+	shared := 0
+	for shared < len(active) && shared < len(other) && active[shared] == other[shared] {
+		shared++
+	}
+
+	entries := append([]data.Entry{}, active[:shared]...)
+	entries = append(entries, &BranchMarker{ID: "branch:active", Title: fmt.Sprintf("── active branch (%d entries) ──", len(active)-shared)})
+	entries = append(entries, active[shared:]...)
+	entries = append(entries, &BranchMarker{ID: "branch:other", Title: fmt.Sprintf("── branch %d/%d, abandoned (%d entries) ──", idx+1, len(branches), len(other)-shared)})
+	entries = append(entries, other[shared:]...)
+	return entries
+}
+
+// BranchStatus describes the branch being viewed, or "" if the selected
+// session does not branch
+func (m Model) BranchStatus() string {
+	branches, idx := m.selectedBranches()
+	if len(branches) < 2 {
+		return ""
+	}
+	status := fmt.Sprintf("[branch %d/%d", idx+1, len(branches))
+	if branches[idx].Active {
+		status += " active"
+	} else if m.CompareBranch {
+		status += " vs active"
+	}
+	return status + "]"
+}
+
+// cycleBranch switches the detail pane to the next (dir > 0) or previous
+// (dir < 0) branch of the selected session
+func (m *Model) cycleBranch(dir int) {
+	branches, idx := m.selectedBranches()
+	if len(branches) < 2 {
+		return
+	}
+	m.Branch = branches[(idx+dir+len(branches))%len(branches)].Leaf.UUID
+	m.BranchSession = m.Selected.Session.FilePath
+	m.DetailScroll = 0
+	m.UpdateDetailContentHeight()
+}
@@ -0,0 +1,51 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/natdempk/claude-mri/internal/data"
+)
+
+func branchMessage(uuid, parent string, at int) *data.Message {
+	msg := &data.Message{UUID: uuid, Type: "user", Timestamp: time.Unix(int64(at), 0)}
+	if parent != "" {
+		msg.ParentUUID = &parent
+	}
+	return msg
+}
+
+func appendEntries(s *data.Session, msgs ...*data.Message) {
+	tail := &data.SessionTail{Offset: s.Offset}
+	for _, msg := range msgs {
+		tail.Entries = append(tail.Entries, msg)
+		tail.Messages = append(tail.Messages, msg)
+	}
+	s.ApplyTail(tail)
+}
+
+func TestSelectedBranch_FollowsLeafAsBranchesArrive(t *testing.T) {
+	session := &data.Session{FilePath: "s.jsonl"}
+	appendEntries(session, branchMessage("u1", "", 1), branchMessage("a1", "u1", 2), branchMessage("a2", "u1", 3))
+	m := Model{Selected: &TreeNode{Type: NodeSession, Session: session}}
+
+	viewed := func() string {
+		branches, idx := m.selectedBranches()
+		return branches[idx].Leaf.UUID
+	}
+	for viewed() != "a1" {
+		m.cycleBranch(1)
+	}
+
+	// A new branch shifts the positions; the view stays on a1
+	appendEntries(session, branchMessage("a3", "u1", 4))
+	if got := viewed(); got != "a1" {
+		t.Errorf("expected branch a1 still viewed after a new branch, got %s", got)
+	}
+
+	// Continuing the viewed branch follows it to its new leaf
+	appendEntries(session, branchMessage("a4", "a1", 5))
+	if got := viewed(); got != "a4" {
+		t.Errorf("expected the continued branch viewed, got %s", got)
+	}
+}
@@ -0,0 +1,64 @@
+package model
+
+import (
+	"os"
+
+	"github.com/natdempk/claude-mri/internal/data"
+	"github.com/natdempk/claude-mri/internal/redact"
+)
+
+// markDeleted flags a session whose file was removed or renamed. It stays
+// in the tree with whatever was loaded until dismissed.
+func (m *Model) markDeleted(session *data.Session) {
+	session.Deleted = true
+	for _, node := range m.Tree {
+		relabelSession(node, session, m.Masker())
+	}
+}
+
+// markProjectDeleted flags the loaded sessions of a removed project
+// directory; sessions never loaded have nothing to show and just disappear
+func (m *Model) markProjectDeleted(path string) {
+	for _, p := range m.Projects {
+		if p.Path != path {
+			continue
+		}
+		for _, s := range p.Sessions {
+			if s.Loaded() {
+				s.Deleted = true
+			}
+		}
+	}
+}
+
+// relabelSession refreshes the labels of the nodes showing session
+func relabelSession(node *TreeNode, session *data.Session, r *redact.Redactor) {
+	if node.Type == NodeSession && node.Session == session {
+		node.Label = sessionLabel(session, r)
+	}
+	for _, child := range node.Children {
+		relabelSession(child, session, r)
+	}
+}
+
+// dismissSelected drops the selected session if its file is gone. The
+// cursor stays on the same row.
+func (m *Model) dismissSelected() {
+	if m.Selected == nil || m.Selected.Type != NodeSession || m.Selected.Session == nil || !m.Selected.Session.Deleted {
+		return
+	}
+	session := m.Selected.Session
+	kept := m.Projects[:0]
+	for _, p := range m.Projects {
+		if p.DismissSession(session) && len(p.Sessions) == 0 {
+			// Drop projects whose directory went with their last session
+			if _, err := os.Stat(p.Path); err != nil {
+				continue
+			}
+		}
+		kept = append(kept, p)
+	}
+	m.Projects = kept
+	m.sortAndRebuildTree()
+	m.DetailScroll = 0
+}
@@ -0,0 +1,52 @@
+package model
+
+import "github.com/natdempk/claude-mri/internal/data"
+
+// DiagnosticGroup is the diagnostics of one project or session, as shown in
+// the diagnostics pane
+type DiagnosticGroup struct {
+	Title       string
+	Diagnostics []data.Diagnostic
+}
+
+// SelectedDiagnostics returns the diagnostics in scope of the selection: the
+// selected session, project or source, or every project
+func (m Model) SelectedDiagnostics() []DiagnosticGroup {
+	if m.Selected != nil && m.Selected.Session != nil {
+		return sessionDiagnostics(nil, m.Selected.Session)
+	}
+	if m.Selected != nil && m.Selected.Type == NodeProject && m.Selected.Project != nil {
+		return projectDiagnostics(nil, m.Selected.Project)
+	}
+	if m.Selected != nil && m.Selected.Type == NodeSource {
+		var groups []DiagnosticGroup
+		for _, child := range m.Selected.Children {
+			if child.Project != nil {
+				groups = projectDiagnostics(groups, child.Project)
+			}
+		}
+		return groups
+	}
+	var groups []DiagnosticGroup
+	for _, p := range m.Projects {
+		groups = projectDiagnostics(groups, p)
+	}
+	return groups
+}
+
+func projectDiagnostics(groups []DiagnosticGroup, p *data.Project) []DiagnosticGroup {
+	if len(p.Diagnostics) > 0 {
+		groups = append(groups, DiagnosticGroup{Title: p.Name, Diagnostics: p.Diagnostics})
+	}
+	for _, s := range p.Sessions {
+		groups = sessionDiagnostics(groups, s)
+	}
+	return groups
+}
+
+func sessionDiagnostics(groups []DiagnosticGroup, s *data.Session) []DiagnosticGroup {
+	if len(s.Diagnostics) == 0 {
+		return groups
+	}
+	return append(groups, DiagnosticGroup{Title: s.FilePath, Diagnostics: s.Diagnostics})
+}
@@ -0,0 +1,90 @@
+package model
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/natdempk/claude-mri/internal/data"
+	"github.com/natdempk/claude-mri/internal/redact"
+)
+
+// ExportTranscript writes entries as a plain-text transcript, masking
+// secrets with r (nil writes them verbatim). Deferred payloads are loaded
+// so the export holds the full content.
+func ExportTranscript(w io.Writer, entries []data.Entry, r *redact.Redactor) error {
+	bw := bufio.NewWriter(w)
+	for _, entry := range entries {
+		switch e := entry.(type) {
+		case *data.Message:
+			if err := exportMessage(bw, e, r); err != nil {
+				return err
+			}
+		case data.Event:
+			fmt.Fprintf(bw, "## %s\n", r.Redact(e.Describe()))
+			if details := r.Redact(e.Details()); details != "" {
+				fmt.Fprintf(bw, "%s\n", details)
+			}
+		}
+		bw.WriteString("\n")
+	}
+	return bw.Flush()
+}
+
+func exportMessage(w *bufio.Writer, msg *data.Message, r *redact.Redactor) error {
+	fmt.Fprintf(w, "## %s", msg.Type)
+	if !msg.Timestamp.IsZero() {
+		fmt.Fprintf(w, " %s", msg.Timestamp.Format("2006-01-02 15:04:05"))
+	}
+	w.WriteString("\n")
+	for i := range msg.Blocks {
+		b := &msg.Blocks[i]
+		if err := b.LoadPayload(); err != nil {
+			return err
+		}
+		switch b.Type {
+		case "text":
+			fmt.Fprintf(w, "%s\n", r.Redact(b.Text))
+		case "thinking":
+			fmt.Fprintf(w, "[thinking]\n%s\n", r.Redact(b.Thinking))
+		case "tool_use":
+			summary := b.ToolName
+			if b.Call != nil {
+				summary = b.Call.Summary(r)
+			}
+			fmt.Fprintf(w, "[tool] %s\n%s\n", summary, r.Redact(b.ToolInput))
+		case "tool_result":
+			label := "[result]"
+			if b.IsError {
+				label = "[error]"
+			}
+			fmt.Fprintf(w, "%s\n%s\n", label, r.Redact(b.Result))
+		}
+	}
+	return nil
+}
+
+// exportSelected writes the entries shown in the detail pane to a text
+// file in the working directory, redacted when redaction is on
+func (m *Model) exportSelected() {
+	entries := m.DetailEntries()
+	if len(entries) == 0 {
+		return
+	}
+	path := "claude-mri-" + m.Selected.Session.ID + ".txt"
+	file, err := os.Create(path)
+	if err != nil {
+		m.notify("Cannot export: %v", err)
+		return
+	}
+	err = ExportTranscript(file, entries, m.Masker())
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		m.notify("Cannot export: %v", err)
+		return
+	}
+	m.inform("Exported %s", path)
+}
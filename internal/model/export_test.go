@@ -0,0 +1,47 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/natdempk/claude-mri/internal/data"
+	"github.com/natdempk/claude-mri/internal/redact"
+)
+
+func TestExportTranscript_Redacts(t *testing.T) {
+	const secret = "sk-ant-REDACTED"
+	use := data.ContentBlock{Type: "tool_use", ToolName: "Bash", ToolID: "t1", ToolInput: `{"command": "curl -H 'x-api-key: ` + secret + `'"}`}
+	result := data.ContentBlock{Type: "tool_result", ToolID: "t1", Result: "echo " + secret}
+	call := &data.ToolCall{ID: "t1", Name: "Bash", Use: &use, Result: &result}
+	use.Call, result.Call = call, call
+	entries := []data.Entry{
+		&data.Message{UUID: "u1", Type: "user", Blocks: []data.ContentBlock{{Type: "text", Text: "my key is " + secret}}},
+		&data.Message{UUID: "a1", Type: "assistant", Blocks: []data.ContentBlock{{Type: "thinking", Thinking: "they pasted " + secret}, use}},
+		&data.Message{UUID: "u2", Type: "user", Blocks: []data.ContentBlock{result}},
+		&data.SummaryEntry{Text: "Rotated " + secret},
+	}
+
+	r, err := redact.New(nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	var sb strings.Builder
+	if err := ExportTranscript(&sb, entries, r); err != nil {
+		t.Fatalf("ExportTranscript: %v", err)
+	}
+	got := sb.String()
+	if strings.Contains(got, secret) {
+		t.Errorf("expected the secret masked, got:\n%s", got)
+	}
+	if n := strings.Count(got, "[redacted]"); n < 6 {
+		t.Errorf("expected every occurrence masked, got %d in:\n%s", n, got)
+	}
+
+	sb.Reset()
+	if err := ExportTranscript(&sb, entries, nil); err != nil {
+		t.Fatalf("ExportTranscript: %v", err)
+	}
+	if !strings.Contains(sb.String(), "my key is "+secret) {
+		t.Errorf("expected verbatim text without a redactor, got:\n%s", sb.String())
+	}
+}
@@ -1,12 +1,13 @@
 package model
 
 import (
-	"os"
-	"path/filepath"
+	"errors"
+	"io/fs"
 
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/natdempk/claude-mri/internal/data"
+	"github.com/natdempk/claude-mri/internal/redact"
 )
 
 // Pane represents which pane has focus
@@ -32,6 +33,11 @@ type Model struct {
 	Tree      []*TreeNode
 	FlatNodes []*TreeNode // flattened visible nodes
 	Watcher   *data.Watcher
+	Index     *data.Index // on-disk session metadata cache, may be nil
+
+	// Sessions with a tail read in flight; true once more changes arrived
+	// while it runs
+	tailing map[*data.Session]bool
 
 	// Navigation - Tree pane
 	Cursor     int
@@ -39,23 +45,42 @@ type Model struct {
 	TreeScroll int // scroll offset for tree pane
 
 	// Navigation - Detail pane
-	Focus              Pane            // which pane has focus
-	DetailScroll       int             // scroll offset (in lines) for detail view
-	DetailContentHeight int            // total height of detail content (set by view)
-	BlockExpanded      map[string]bool // which blocks are expanded (by message UUID)
-	DetailExpandAll    bool            // auto-expand new messages when true
+	Focus               Pane            // which pane has focus
+	DetailScroll        int             // scroll offset (in lines) for detail view
+	DetailContentHeight int             // total height of detail content (set by view)
+	BlockExpanded       map[string]bool // which blocks are expanded (by message UUID)
+	DetailExpandAll     bool            // auto-expand new messages when true
+
+	// Branch view - Detail pane
+	Branch        string // leaf UUID of the branch viewed ("" = active)
+	BranchSession string // file path of the session Branch applies to
+	CompareBranch bool   // show the selected branch next to the active one
+
+	// Subagent navigation - sessions stepped out of, innermost last
+	AgentTrail []AgentStep
+
+	// Token usage screen
+	Analytics Analytics
+
+	// Secret redaction, toggled live
+	Redactor  *redact.Redactor
+	Redacting bool
 
 	// UI state
-	FollowMode bool
-	SortMode   SortMode
-	Ready      bool
-	Width      int
-	Height     int
-	TreeWidth  int
-	DetailView viewport.Model
+	ShowDiagnostics bool // detail pane lists parse diagnostics instead
+	FollowMode      bool
+	SortMode        SortMode
+	Ready           bool
+	Width           int
+	Height          int
+	TreeWidth       int
+	DetailView      viewport.Model
 
-	// Paths
-	BasePath string
+	// Projects directories, each shown as a source when there are several
+	Roots []data.Root
+
+	// Status bar: errors, their history and background progress
+	Status Status
 }
 
 // TreeHeight returns the visible height of the tree pane
@@ -63,26 +88,80 @@ func (m Model) TreeHeight() int {
 	return m.Height - 4 // account for header, border, help
 }
 
-// fileEventMsg wraps a file event
-type fileEventMsg data.FileEvent
+// fileEventsMsg carries a batch of file changes from the watcher
+type fileEventsMsg []data.FileEvent
+
+// Options are the startup settings, from the config file and flags
+type Options struct {
+	Roots     []data.Root // empty for the default roots
+	SortMode  SortMode
+	Follow    bool
+	TreeWidth int
+	Redact    bool
+}
+
+// DefaultOptions returns the settings used when nothing is configured
+func DefaultOptions() Options {
+	return Options{Follow: true, TreeWidth: 40, Redact: true}
+}
 
 // NewModel creates a new model
-func NewModel() Model {
-	home, _ := os.UserHomeDir()
-	basePath := filepath.Join(home, ".claude", "projects")
+func NewModel(opts Options) Model {
+	roots := opts.Roots
+	if len(roots) == 0 {
+		roots = data.DefaultRoots()
+	}
+	if opts.TreeWidth == 0 {
+		opts.TreeWidth = DefaultOptions().TreeWidth
+	}
 
 	m := Model{
-		BasePath:      basePath,
-		FollowMode:    true,
-		TreeWidth:     40,
+		Roots:         roots,
+		SortMode:      opts.SortMode,
+		FollowMode:    opts.Follow,
+		TreeWidth:     opts.TreeWidth,
 		Focus:         TreePane,
 		BlockExpanded: make(map[string]bool),
+		tailing:       make(map[*data.Session]bool),
+		Status:        newStatus(),
+	}
+
+	// Open the metadata index; without it every scan peeks into each file
+	if path := data.DefaultIndexPath(); path != "" {
+		if ix, err := data.OpenIndex(path); err == nil {
+			m.Index = ix
+		} else {
+			m.notify("Cannot open index: %v", err)
+		}
+	}
+
+	// Layer user price overrides over the built-in table
+	if path := data.DefaultPricesPath(); path != "" {
+		if err := data.LoadPrices(path); err != nil {
+			m.notify("Cannot load prices: %v", err)
+		}
+	}
+
+	// Redact secrets by default; a broken user pattern file disables only
+	// the user patterns
+	patterns, err := redact.LoadPatterns(redact.DefaultPatternsPath())
+	if err != nil {
+		m.notify("Cannot load redaction patterns: %v", err)
 	}
+	r, err := redact.New(patterns)
+	if err != nil {
+		m.notify("Ignoring redaction patterns: %v", err)
+		r, _ = redact.New(nil)
+	}
+	m.Redactor = r
+	m.Redacting = opts.Redact
 
 	// Create watcher
-	w, err := data.NewWatcher(basePath)
+	w, err := data.NewWatcher(rootPaths(roots)...)
 	if err == nil {
 		m.Watcher = w
+	} else {
+		m.notify("Live updates disabled: %v", err)
 	}
 
 	return m
@@ -92,6 +171,8 @@ func NewModel() Model {
 func (m Model) Init() tea.Cmd {
 	cmds := []tea.Cmd{
 		m.loadProjects,
+		m.listenScans,
+		activityTick(),
 	}
 	if m.Watcher != nil {
 		m.Watcher.Start()
@@ -101,11 +182,20 @@ func (m Model) Init() tea.Cmd {
 }
 
 func (m Model) loadProjects() tea.Msg {
-	projects, err := data.ScanProjects(m.BasePath)
-	if err != nil {
-		return errMsg{err}
+	seq := scanSeq.Add(1)
+	msg := projectsLoadedMsg{seq: seq}
+	msg.err = m.Index.Scan(func() {
+		msg.projects = data.ScanRootsProgress(m.Roots, m.Index, m.reportScan(seq))
+	})
+	return msg
+}
+
+func rootPaths(roots []data.Root) []string {
+	paths := make([]string, len(roots))
+	for i, r := range roots {
+		paths[i] = r.Path
 	}
-	return projectsLoadedMsg{projects}
+	return paths
 }
 
 // watchFiles returns file events from the watcher
@@ -114,21 +204,55 @@ func (m Model) watchFiles() tea.Msg {
 		return nil
 	}
 	select {
-	case event := <-m.Watcher.Events:
-		return fileEventMsg(event)
+	case events := <-m.Watcher.Events:
+		return fileEventsMsg(events)
 	case err := <-m.Watcher.Errors:
-		return errMsg{err}
+		return watchErrMsg{err}
 	}
 }
 
 type projectsLoadedMsg struct {
 	projects []*data.Project
+	seq      int64
+	err      error // saving the index failed; the projects are still good
+}
+
+// sessionTailMsg carries lines appended to a session, or all of it for a
+// session loaded in the background
+type sessionTailMsg struct {
+	session *data.Session
+	tail    *data.SessionTail
+	err     error
+}
+
+// sessionDeletedMsg reports a session whose file disappeared
+type sessionDeletedMsg struct {
+	session *data.Session
+}
+
+// readSessionTail returns a command that reads newly appended lines of a
+// session. The read starts from the session's current position; the command
+// never touches the session itself, which Update may change meanwhile.
+func readSessionTail(session *data.Session) tea.Cmd {
+	pos := session.TailPosition()
+	return func() tea.Msg {
+		tail, err := data.ReadTail(pos)
+		if errors.Is(err, fs.ErrNotExist) {
+			return sessionDeletedMsg{session}
+		}
+		return sessionTailMsg{session: session, tail: tail, err: err}
+	}
 }
 
 type errMsg struct {
 	err error
 }
 
+// watchErrMsg is an error reported by the file watcher
+type watchErrMsg struct {
+	err error
+}
+
 // View renders the model (stub - actual rendering in ui package)
 func (m Model) View() string {
 	return "Loading..."
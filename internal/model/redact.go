@@ -0,0 +1,30 @@
+package model
+
+import (
+	"github.com/natdempk/claude-mri/internal/redact"
+)
+
+// Masker returns the redactor masking secrets in tree labels and the
+// detail pane, or nil while redaction is off. Redactor methods accept nil
+// and then leave text as is.
+func (m Model) Masker() *redact.Redactor {
+	if !m.Redacting {
+		return nil
+	}
+	return m.Redactor
+}
+
+// Redact masks secrets in s when redaction is on
+func (m Model) Redact(s string) string {
+	return m.Masker().Redact(s)
+}
+
+// toggleRedaction switches redaction and rebuilds the labels it applies to
+func (m *Model) toggleRedaction() {
+	if m.Redactor == nil {
+		return
+	}
+	m.Redacting = !m.Redacting
+	m.sortAndRebuildTree()
+	m.UpdateDetailContentHeight()
+}
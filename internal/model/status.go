@@ -0,0 +1,193 @@
+package model
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/natdempk/claude-mri/internal/data"
+	"github.com/natdempk/claude-mri/internal/debug"
+)
+
+const (
+	// noticeTTL is how long a new error stays in the status bar
+	noticeTTL = 8 * time.Second
+	// maxNotices caps the error history
+	maxNotices = 200
+	// backgroundLoadSize is the file size from which sessions load in the
+	// background instead of blocking the UI
+	backgroundLoadSize = 4 << 20
+)
+
+// Notice is an error shown in the status bar and kept in the history
+type Notice struct {
+	Time  time.Time
+	Text  string
+	Count int // repeats of the same error in a row
+}
+
+// Status holds the status bar: recent errors, their history, the outcome
+// of the last action and progress of background work
+type Status struct {
+	Notices     []Notice // oldest first
+	HistoryOpen bool
+	Scroll      int    // history scroll offset, in notices from the newest
+	Info        Notice // latest outcome of a user action; not kept in the history
+
+	Scan     *ScanProgress           // nil when no scan is running
+	lastScan int64                   // sequence number of the last finished scan
+	scans    chan scanProgressMsg    // progress reported by running scans
+	Loading  map[*data.Session]int64 // sessions loading in the background, by file size
+	queued   []*data.Session         // background loads to start after this update
+}
+
+// ScanProgress counts project folders scanned out of those found so far
+type ScanProgress struct {
+	Done, Total int
+}
+
+// scanProgressMsg reports how far scan number seq has got
+type scanProgressMsg struct {
+	seq int64
+	ScanProgress
+}
+
+// scanSeq numbers scans so progress arriving after a scan finished is
+// ignored
+var scanSeq atomic.Int64
+
+func newStatus() Status {
+	return Status{
+		Loading: make(map[*data.Session]int64),
+		scans:   make(chan scanProgressMsg, 1),
+	}
+}
+
+// notify records an error for the status bar and history
+func (m *Model) notify(format string, args ...any) {
+	text := fmt.Sprintf(format, args...)
+	debug.Log("notice: %s", text)
+	now := time.Now()
+	if n := len(m.Status.Notices); n > 0 && m.Status.Notices[n-1].Text == text {
+		m.Status.Notices[n-1].Count++
+		m.Status.Notices[n-1].Time = now
+		return
+	}
+	m.Status.Notices = append(m.Status.Notices, Notice{Time: now, Text: text, Count: 1})
+	if len(m.Status.Notices) > maxNotices {
+		m.Status.Notices = m.Status.Notices[len(m.Status.Notices)-maxNotices:]
+	}
+}
+
+// inform reports the outcome of a user action in the status bar. Unlike
+// notify it is not an error and doesn't go into the history.
+func (m *Model) inform(format string, args ...any) {
+	m.Status.Info = Notice{Time: time.Now(), Text: fmt.Sprintf(format, args...), Count: 1}
+}
+
+// CurrentInfo returns the latest outcome message while it is still fresh
+func (m Model) CurrentInfo(now time.Time) (Notice, bool) {
+	if info := m.Status.Info; info.Text != "" && now.Sub(info.Time) < noticeTTL {
+		return info, true
+	}
+	return Notice{}, false
+}
+
+// CurrentNotice returns the latest error while it is still fresh
+func (m Model) CurrentNotice(now time.Time) (Notice, bool) {
+	if n := len(m.Status.Notices); n > 0 && now.Sub(m.Status.Notices[n-1].Time) < noticeTTL {
+		return m.Status.Notices[n-1], true
+	}
+	return Notice{}, false
+}
+
+// reportScan returns a progress callback for a scan that hands the latest
+// count to the UI, dropping counts it hasn't picked up yet
+func (m Model) reportScan(seq int64) func(done, total int) {
+	return func(done, total int) {
+		msg := scanProgressMsg{seq: seq, ScanProgress: ScanProgress{Done: done, Total: total}}
+		select {
+		case <-m.Status.scans:
+		default:
+		}
+		select {
+		case m.Status.scans <- msg:
+		default:
+		}
+	}
+}
+
+// listenScans returns scan progress as it is reported
+func (m Model) listenScans() tea.Msg {
+	return <-m.Status.scans
+}
+
+// noteUnreadableRoots reports roots the last scan couldn't read
+func (m *Model) noteUnreadableRoots() {
+	for _, root := range m.Roots {
+		for _, p := range m.Projects {
+			if p.Path != root.Path || p.Source != root.Label {
+				continue
+			}
+			for _, d := range p.Diagnostics {
+				if d.Kind == data.DiagUnreadable {
+					m.notify("Cannot read %s: %s", root.Path, d.Err)
+				}
+			}
+		}
+	}
+}
+
+// loadInBackground queues a large session to load off the UI thread,
+// returning false if it is small enough to load right away
+func (m *Model) loadInBackground(session *data.Session) bool {
+	if _, loading := m.Status.Loading[session]; loading {
+		return true
+	}
+	info, err := os.Stat(session.FilePath)
+	if err != nil || info.Size() < backgroundLoadSize {
+		return false
+	}
+	m.Status.Loading[session] = info.Size()
+	m.Status.queued = append(m.Status.queued, session)
+	return true
+}
+
+// startQueuedLoads adds commands for the background loads queued during an
+// update
+func (m Model) startQueuedLoads(cmd tea.Cmd) (tea.Model, tea.Cmd) {
+	if len(m.Status.queued) == 0 {
+		return m, cmd
+	}
+	cmds := []tea.Cmd{cmd}
+	for _, session := range m.Status.queued {
+		cmds = append(cmds, m.tailSession(session))
+	}
+	m.Status.queued = nil
+	return m, tea.Batch(cmds...)
+}
+
+// IsLoading reports whether a session is being loaded in the background,
+// and its file size
+func (m Model) IsLoading(session *data.Session) (int64, bool) {
+	size, ok := m.Status.Loading[session]
+	return size, ok
+}
+
+func (m Model) handleHistoryKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "!", "esc":
+		m.Status.HistoryOpen = false
+	case "j", "down":
+		m.Status.Scroll = min(m.Status.Scroll+1, max(0, len(m.Status.Notices)-1))
+	case "k", "up":
+		m.Status.Scroll = max(0, m.Status.Scroll-1)
+	case "g", "home":
+		m.Status.Scroll = 0
+	case "G", "end":
+		m.Status.Scroll = max(0, len(m.Status.Notices)-1)
+	}
+	return m, nil
+}
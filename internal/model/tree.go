@@ -1,9 +1,12 @@
 package model
 
 import (
+	"fmt"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/natdempk/claude-mri/internal/data"
+	"github.com/natdempk/claude-mri/internal/redact"
 )
 
 // NodeType identifies the type of tree node
@@ -14,6 +17,8 @@ const (
 	NodeSession
 	NodeMessage
 	NodeBlock
+	NodeEvent  // non-message entry: summary, system, snapshot...
+	NodeSource // a projects root, shown when browsing several
 )
 
 // TreeNode represents a node in the navigation tree
@@ -22,12 +27,14 @@ type TreeNode struct {
 	ID       string
 	Label    string
 	Expanded bool
+	IsError  bool // node holds a failed tool call
 	Children []*TreeNode
 	// References to underlying data
 	Project *data.Project
 	Session *data.Session
 	Message *data.Message
 	Block   *data.ContentBlock
+	Event   data.Event
 	// for rendering indentation
 	depth int
 }
@@ -48,30 +55,92 @@ func (n *TreeNode) IsExpandable() bool {
 	}
 }
 
-// BuildTree creates the tree structure from projects
-func BuildTree(projects []*data.Project) []*TreeNode {
+// BuildTree creates the tree structure from projects. Projects from
+// several roots are grouped under a node per source, in order of first
+// appearance. Labels are masked with r, which is nil while redaction is off.
+func BuildTree(projects []*data.Project, r *redact.Redactor) []*TreeNode {
 	nodes := make([]*TreeNode, 0, len(projects))
+	sources := make(map[string]*TreeNode)
 	for _, p := range projects {
-		nodes = append(nodes, buildProjectNode(p))
+		if _, ok := sources[p.Source]; !ok {
+			source := &TreeNode{Type: NodeSource, ID: "source:" + p.Source, Label: p.Source}
+			sources[p.Source] = source
+			nodes = append(nodes, source)
+		}
+		source := sources[p.Source]
+		source.Children = append(source.Children, buildProjectNode(p, r))
+	}
+	if len(nodes) == 1 {
+		return nodes[0].Children
 	}
 	return nodes
 }
 
-func buildProjectNode(p *data.Project) *TreeNode {
+func buildProjectNode(p *data.Project, r *redact.Redactor) *TreeNode {
 	node := &TreeNode{
 		Type:     NodeProject,
 		ID:       p.Path,
-		Label:    p.Name,
+		Label:    projectLabel(p),
 		Expanded: false,
 		Project:  p,
 	}
 	for _, s := range p.Sessions {
-		node.Children = append(node.Children, buildSessionNode(s))
+		// Linked agents are nested under the session that spawned them
+		if s.Parent != nil {
+			continue
+		}
+		node.Children = append(node.Children, buildSessionNode(s, r))
+	}
+	return node
+}
+
+func buildSessionNode(s *data.Session, r *redact.Redactor) *TreeNode {
+	node := &TreeNode{
+		Type:     NodeSession,
+		ID:       s.FilePath,
+		Label:    sessionLabel(s, r),
+		Expanded: false,
+		Session:  s,
 	}
+	node.Children = sessionChildren(s, nil, r)
 	return node
 }
 
-func buildSessionNode(s *data.Session) *TreeNode {
+// agentNodes builds nodes for subagents not yet matched to a Task call
+func agentNodes(s *data.Session, r *redact.Redactor) []*TreeNode {
+	var nodes []*TreeNode
+	for _, a := range s.UnlinkedSubagents() {
+		nodes = append(nodes, buildSessionNode(a, r))
+	}
+	return nodes
+}
+
+// sessionChildren builds the nodes for a session's active path, marking
+// fork points. Expanded state is carried over from a previous build.
+func sessionChildren(s *data.Session, old []*TreeNode, r *redact.Redactor) []*TreeNode {
+	expanded := make(map[string]bool, len(old))
+	for _, n := range old {
+		if n.Expanded {
+			expanded[n.ID] = true
+		}
+	}
+
+	graph := s.Graph()
+	var children []*TreeNode
+	for _, e := range graph.ActivePath() {
+		node := BuildEntryNode(e, r)
+		if gn := graph.Nodes[e.EntryID()]; gn != nil && gn.IsFork() {
+			node.Label += fmt.Sprintf(" ⑂%d", len(gn.Children))
+		}
+		node.Expanded = expanded[node.ID]
+		children = append(children, node)
+	}
+	return append(children, agentNodes(s, r)...)
+}
+
+// sessionLabel returns the tree label for a session, with its cost and
+// error count once known
+func sessionLabel(s *data.Session, r *redact.Redactor) string {
 	label := s.ID
 	if len(s.ID) > 8 {
 		label = s.ID[:8] + "..."
@@ -79,62 +148,158 @@ func buildSessionNode(s *data.Session) *TreeNode {
 	if s.IsAgent {
 		label = "agent-" + s.AgentID
 	}
-	node := &TreeNode{
-		Type:     NodeSession,
-		ID:       s.FilePath,
-		Label:    label,
-		Expanded: false,
-		Session:  s,
+	if s.GitBranch != "" && !s.IsAgent {
+		label += " ⎇" + truncate(r, s.GitBranch, 16)
 	}
-	for _, m := range s.Messages {
-		node.Children = append(node.Children, BuildMessageNode(m))
+	if cost := s.TotalCost(); cost > 0 {
+		label += " " + data.FormatCost(cost)
 	}
-	return node
+	if n := s.ErrorCount(); n > 0 {
+		label += fmt.Sprintf(" ✗%d", n)
+	}
+	if n := len(s.Diagnostics); n > 0 {
+		label += fmt.Sprintf(" ⚠%d", n)
+	}
+	if s.Deleted {
+		label += " (deleted)"
+	}
+	return label
+}
+
+// projectLabel returns the tree label for a project, with its cost and the
+// number of parse diagnostics found so far
+func projectLabel(p *data.Project) string {
+	label := p.Name
+	if cost := p.Cost(); cost > 0 {
+		label += " " + data.FormatCost(cost)
+	}
+	if n := p.DiagnosticCount(); n > 0 {
+		label += fmt.Sprintf(" ⚠%d", n)
+	}
+	return label
+}
+
+// refreshProjectLabels updates project costs and badges after sessions
+// were loaded or grew
+func (m *Model) refreshProjectLabels() {
+	for _, node := range m.Tree {
+		projects := []*TreeNode{node}
+		if node.Type == NodeSource {
+			projects = node.Children
+		}
+		for _, p := range projects {
+			if p.Type == NodeProject && p.Project != nil {
+				p.Label = projectLabel(p.Project)
+			}
+		}
+	}
+}
+
+// BuildEntryNode builds the tree node for any session entry
+func BuildEntryNode(e data.Entry, r *redact.Redactor) *TreeNode {
+	switch e := e.(type) {
+	case *data.Message:
+		return BuildMessageNode(e, r)
+	case data.Event:
+		return buildEventNode(e, r)
+	}
+	return &TreeNode{Type: NodeEvent, ID: e.EntryID(), Label: e.EntryType()}
 }
 
-func BuildMessageNode(m *data.Message) *TreeNode {
+func buildEventNode(e data.Event, r *redact.Redactor) *TreeNode {
+	icon := "•"
+	isError := false
+	switch e := e.(type) {
+	case *data.SummaryEntry:
+		icon = "📋"
+	case *data.SystemEntry:
+		icon = "⚙"
+		if e.IsCompaction() {
+			icon = "⟳"
+		}
+		isError = e.IsError()
+	case *data.FileHistorySnapshotEntry:
+		icon = "📸"
+	case *data.QueueOperationEntry:
+		icon = "⏳"
+	case *data.UnknownEntry:
+		icon = "?"
+	}
+	return &TreeNode{
+		Type:    NodeEvent,
+		ID:      e.EntryID(),
+		Label:   icon + " " + truncate(r, e.Describe(), 30),
+		IsError: isError,
+		Event:   e,
+	}
+}
+
+func BuildMessageNode(m *data.Message, r *redact.Redactor) *TreeNode {
 	icon := "👤"
 	if m.Type == "assistant" {
 		icon = "🤖"
 	}
-	label := icon + " " + truncate(getMessagePreview(m), 30)
+	label := icon + " " + truncate(r, getMessagePreview(m), 30)
 
 	node := &TreeNode{
 		Type:     NodeMessage,
 		ID:       m.UUID,
 		Label:    label,
 		Expanded: false,
+		IsError:  m.HasFailedToolCall(),
 		Message:  m,
 	}
 
 	// Add blocks as children for detailed view
 	for i := range m.Blocks {
 		b := &m.Blocks[i]
-		node.Children = append(node.Children, buildBlockNode(b))
+		// Paired results are shown as part of their tool_use node
+		if b.Type == "tool_result" && b.Call != nil {
+			continue
+		}
+		node.Children = append(node.Children, buildBlockNode(b, r))
 	}
 	return node
 }
 
-func buildBlockNode(b *data.ContentBlock) *TreeNode {
+func buildBlockNode(b *data.ContentBlock, r *redact.Redactor) *TreeNode {
 	var label string
 	switch b.Type {
 	case "thinking":
 		label = "💭 thinking..."
 	case "text":
-		label = "📝 " + truncate(b.Text, 25)
+		label = "📝 " + truncate(r, b.Text, 25)
 	case "tool_use":
-		label = "🔧 " + b.ToolName
+		icon := "🔧 "
+		if b.Failed() {
+			icon = "❌ "
+		}
+		if b.Call != nil {
+			// Summary shortens only the input, keeping the outcome
+			label = icon + b.Call.Summary(r)
+		} else {
+			label = icon + b.ToolName
+		}
 	case "tool_result":
 		label = "📤 result"
+		if b.IsError {
+			label = "❌ error result"
+		}
 	default:
 		label = b.Type
 	}
-	return &TreeNode{
-		Type:  NodeBlock,
-		ID:    b.ToolID,
-		Label: label,
-		Block: b,
+	node := &TreeNode{
+		Type:    NodeBlock,
+		ID:      b.ToolID,
+		Label:   label,
+		IsError: b.Failed(),
+		Block:   b,
 	}
+	// Task calls lead to the subagent that ran them
+	if b.Type == "tool_use" && b.Call != nil && b.Call.Agent != nil {
+		node.Children = append(node.Children, buildSessionNode(b.Call.Agent, r))
+	}
+	return node
 }
 
 func getMessagePreview(m *data.Message) string {
@@ -146,16 +311,23 @@ func getMessagePreview(m *data.Message) string {
 			}
 		case "tool_use":
 			return b.ToolName + "()"
+		case "tool_result":
+			if b.Call != nil {
+				return "↳ " + b.Call.Name + " result"
+			}
+			return "↳ result"
 		}
 	}
 	return "(empty)"
 }
 
-func truncate(s string, max int) string {
+func truncate(r *redact.Redactor, s string, max int) string {
+	// Redact before cutting, so truncation can't hide a secret's shape
+	s = r.Redact(s)
 	// Remove newlines
 	s = strings.ReplaceAll(s, "\n", " ")
-	if len(s) <= max {
+	if utf8.RuneCountInString(s) <= max {
 		return s
 	}
-	return s[:max-3] + "..."
+	return string([]rune(s)[:max-3]) + "..."
 }
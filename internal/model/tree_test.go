@@ -0,0 +1,26 @@
+package model
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/natdempk/claude-mri/internal/data"
+)
+
+func TestBuildBlockNode_ToolSummaryKeepsOutcome(t *testing.T) {
+	start := time.Date(2025, 12, 22, 22, 20, 49, 0, time.UTC)
+	use := &data.ContentBlock{Type: "tool_use", ToolName: "Bash", ToolInput: `{"command": "go test ./internal/data/... -run 'TestWatcher' -count=1 -v"}`}
+	result := &data.ContentBlock{Type: "tool_result", Result: "ok\nok"}
+	use.Call = &data.ToolCall{Name: "Bash", Use: use, Result: result, StartTime: start, EndTime: start.Add(340 * time.Millisecond)}
+
+	label := buildBlockNode(use, nil).Label
+	if !strings.HasSuffix(label, "→ 2 lines, 340ms") {
+		t.Errorf("expected the outcome kept after a long command, got %q", label)
+	}
+
+	if got := truncate(nil, strings.Repeat("é", 40), 30); !utf8.ValidString(got) || got != strings.Repeat("é", 27)+"..." {
+		t.Errorf("expected a cut on a rune boundary, got %q", got)
+	}
+}
@@ -1,9 +1,12 @@
 package model
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
@@ -15,6 +18,12 @@ import (
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	defer debug.Time(fmt.Sprintf("Update(%T)", msg))()
 
+	next, cmd := m.update(msg)
+	// Large sessions selected during the update load in the background
+	return next.(Model).startQueuedLoads(cmd)
+}
+
+func (m Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		debug.Log("KeyMsg: %s", msg.String())
@@ -31,6 +40,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case projectsLoadedMsg:
 		debug.Log("projectsLoadedMsg: %d projects", len(msg.projects))
 		defer debug.Time("projectsLoadedMsg processing")()
+		m.Status.lastScan = max(m.Status.lastScan, msg.seq)
+		m.Status.Scan = nil
+		if msg.err != nil {
+			m.notify("Cannot save index: %v", msg.err)
+		}
 		// Preserve expanded state before rebuild
 		expanded := m.getExpandedIDs()
 		selectedID := ""
@@ -38,28 +52,20 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			selectedID = m.Selected.ID
 		}
 
-		m.Projects = msg.projects
+		// Keep already-parsed sessions instead of reparsing them
+		data.ReuseLoadedSessions(m.Projects, msg.projects)
+		// Deleted sessions stay until dismissed
+		m.Projects = data.KeepDeletedSessions(m.Projects, msg.projects)
 		m.sortProjects() // Apply current sort mode
-		m.Tree = BuildTree(m.Projects)
+		m.Tree = BuildTree(m.Projects, m.Masker())
 
 		// Restore expanded state
 		m.restoreExpandedState(expanded)
 		// Reload messages for expanded sessions (they have new session objects)
 		m.reloadExpandedSessions()
 		m.flattenTree()
-
-		// Restore selection
-		if selectedID != "" {
-			for i, node := range m.FlatNodes {
-				if node.ID == selectedID {
-					m.Cursor = i
-					m.Selected = node
-					break
-				}
-			}
-		} else if len(m.FlatNodes) > 0 {
-			m.Selected = m.FlatNodes[0]
-		}
+		m.restoreSelection(selectedID)
+		m.noteUnreadableRoots()
 		// Reload messages for selected session (it's a new object after rebuild)
 		m.loadSelectedSession()
 		m.ensureCursorVisible()
@@ -70,21 +76,127 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
-	case fileEventMsg:
-		debug.Log("fileEventMsg: %s", msg.Path)
-		// Reload projects on file change
-		// In follow mode, this will auto-update the tree
-		return m, tea.Batch(m.loadProjects, m.watchFiles)
+	case fileEventsMsg:
+		return m.handleFileEvents(msg)
+
+	case activityTickMsg:
+		// Nothing to update; the redraw re-evaluates the live indicators
+		return m, activityTick()
+
+	case sessionDeletedMsg:
+		delete(m.Status.Loading, msg.session)
+		delete(m.tailing, msg.session)
+		m.markDeleted(msg.session)
+		return m, nil
+
+	case sessionTailMsg:
+		_, background := m.Status.Loading[msg.session]
+		delete(m.Status.Loading, msg.session)
+		changed := m.tailing[msg.session]
+		delete(m.tailing, msg.session)
+		switch {
+		case msg.err != nil:
+			m.notify("Cannot read session %s: %v", msg.session.ID, msg.err)
+		case msg.tail.Since != msg.session.Offset:
+			// The session was reloaded while this read ran
+			debug.Log("sessionTailMsg: %s stale read from %d, now at %d", msg.session.ID, msg.tail.Since, msg.session.Offset)
+		default:
+			debug.Log("sessionTailMsg: %s +%d entries (reset=%v)", msg.session.ID, len(msg.tail.Entries), msg.tail.Reset)
+			m.applySessionTail(msg.session, msg.tail)
+			if background && m.Selected != nil && m.Selected.Session == msg.session {
+				m.rebuildSessionChildren()
+				m.flattenTree()
+			}
+		}
+		// Pick up what was appended while the read ran
+		if changed && msg.session.Loaded() && !msg.session.Deleted {
+			return m, m.tailSession(msg.session)
+		}
+		return m, nil
+
+	case scanProgressMsg:
+		if msg.seq > m.Status.lastScan {
+			m.Status.Scan = &msg.ScanProgress
+		}
+		return m, m.listenScans
 
 	case errMsg:
-		// Could display error, for now just ignore
+		m.notify("%v", msg.err)
 		return m, nil
+
+	case watchErrMsg:
+		m.notify("Watcher: %v", msg.err)
+		return m, m.watchFiles
 	}
 
 	return m, nil
 }
 
+// handleFileEvents refreshes only the sessions in a batch of file changes,
+// rescanning once if any belong to unknown sessions or projects
+func (m Model) handleFileEvents(events []data.FileEvent) (tea.Model, tea.Cmd) {
+	cmds := []tea.Cmd{m.watchFiles}
+	rescan, touched := false, false
+	now := time.Now()
+	for _, ev := range events {
+		debug.Log("file event: %s %s", ev.Kind, ev.Path)
+		session := m.findSession(ev.Path)
+		if session != nil && (ev.Kind == data.ChangeAppended || ev.Kind == data.ChangeCreated) {
+			noteWrite(session, now)
+		}
+		switch {
+		case ev.IsProject && (ev.Kind == data.ChangeRemoved || ev.Kind == data.ChangeRenamed):
+			m.markProjectDeleted(ev.Path)
+			rescan = true
+		case ev.Kind == data.ChangeRemoved || ev.Kind == data.ChangeRenamed:
+			if session != nil && session.Loaded() && !session.Deleted {
+				m.markDeleted(session)
+			} else if session != nil && !session.Deleted {
+				rescan = true
+			}
+		case ev.IsProject || session == nil || session.Deleted:
+			// New files, or a deleted one created again
+			rescan = true
+		case !session.Loaded():
+			// Not parsed yet; the activity is noted above. A background
+			// load in flight reads the change once it lands.
+			if _, reading := m.tailing[session]; reading {
+				m.tailing[session] = true
+			}
+			touched = true
+		default:
+			// Parse only the appended lines
+			cmds = append(cmds, m.tailSession(session))
+		}
+	}
+	if rescan {
+		cmds = append(cmds, m.loadProjects)
+	} else if touched && m.SortMode == SortRecent {
+		m.sortAndRebuildTree()
+	}
+	return m, tea.Batch(cmds...)
+}
+
+// tailSession returns a command reading what was appended to a session.
+// Only one read per session runs at a time: while one is in flight this
+// returns nil and the read is repeated once it lands.
+func (m *Model) tailSession(session *data.Session) tea.Cmd {
+	if _, reading := m.tailing[session]; reading {
+		m.tailing[session] = true
+		return nil
+	}
+	m.tailing[session] = false
+	return readSessionTail(session)
+}
+
 func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.Analytics.Open && msg.String() != "q" && msg.String() != "ctrl+c" {
+		return m.handleAnalyticsKey(msg)
+	}
+	if m.Status.HistoryOpen && msg.String() != "q" && msg.String() != "ctrl+c" {
+		return m.handleHistoryKey(msg)
+	}
+
 	switch msg.String() {
 	case "q", "ctrl+c":
 		if m.Watcher != nil {
@@ -110,6 +222,23 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.scrollToEnd()
 		}
 
+	case "t":
+		m.Analytics.Open = true
+
+	case "r":
+		m.toggleRedaction()
+
+	case "w":
+		m.exportSelected()
+
+	case "!":
+		m.Status.HistoryOpen = true
+		m.Status.Scroll = 0
+
+	case "D":
+		m.ShowDiagnostics = !m.ShowDiagnostics
+		m.DetailScroll = 0
+
 	case "s":
 		// Toggle sort mode
 		if m.SortMode == SortAlphabetical {
@@ -193,14 +322,17 @@ func (m Model) handleTreeKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.ensureCursorVisible()
 			m.loadSelectedSession()
 		}
+
+	case "x":
+		m.dismissSelected()
 	}
 
 	return m, nil
 }
 
 func (m Model) handleDetailKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	messages := m.getSelectedMessages()
-	if len(messages) == 0 {
+	entries := m.getSelectedEntries()
+	if len(entries) == 0 {
 		return m, nil
 	}
 
@@ -237,19 +369,53 @@ func (m Model) handleDetailKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "enter", "l", "right":
 		// Expand all messages and enable auto-expand for new ones
 		m.DetailExpandAll = true
-		for _, msg := range messages {
-			m.BlockExpanded[msg.UUID] = true
+		for _, e := range entries {
+			m.BlockExpanded[e.EntryID()] = true
 		}
 		m.UpdateDetailContentHeight()
 
 	case "h", "left":
 		// Collapse all messages and disable auto-expand
 		m.DetailExpandAll = false
-		for _, msg := range messages {
-			m.BlockExpanded[msg.UUID] = false
+		for _, e := range entries {
+			m.BlockExpanded[e.EntryID()] = false
 		}
 		m.UpdateDetailContentHeight()
 
+	case "b":
+		// View the next conversation branch
+		m.cycleBranch(1)
+
+	case "B":
+		// View the previous conversation branch
+		m.cycleBranch(-1)
+
+	case "c":
+		// Compare the viewed branch with the active one
+		m.CompareBranch = !m.CompareBranch
+		m.DetailScroll = 0
+		m.UpdateDetailContentHeight()
+
+	case "a":
+		// Step into the subagent of the Task call at the scroll position
+		m.stepIntoAgent()
+
+	case "u":
+		// Step back out to the spawning session
+		m.stepOutOfAgent()
+
+	case "p":
+		// Load deferred large payloads of the message at the scroll position
+		m.loadPayloadsAtScroll()
+
+	case "e":
+		// Jump to the next failed tool call
+		m.jumpToFailedToolCall(1)
+
+	case "E":
+		// Jump to the previous failed tool call
+		m.jumpToFailedToolCall(-1)
+
 	case "esc":
 		// Return to tree pane
 		m.Focus = TreePane
@@ -258,15 +424,9 @@ func (m Model) handleDetailKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
-// getSelectedMessages returns messages for the currently selected session
-func (m *Model) getSelectedMessages() []*data.Message {
-	if m.Selected == nil || m.Selected.Type != NodeSession {
-		return nil
-	}
-	if m.Selected.Session == nil {
-		return nil
-	}
-	return m.Selected.Session.Messages
+// getSelectedEntries returns the entries shown for the selected session
+func (m *Model) getSelectedEntries() []data.Entry {
+	return m.DetailEntries()
 }
 
 // DetailHeight returns visible height of detail pane
@@ -320,23 +480,30 @@ func (m *Model) sortAndRebuildTree() {
 	}
 
 	// Rebuild tree
-	m.Tree = BuildTree(m.Projects)
+	m.Tree = BuildTree(m.Projects, m.Masker())
 	m.restoreExpandedState(expanded)
 	m.reloadExpandedSessions()
 	m.flattenTree()
+	m.restoreSelection(selectedID)
+	m.loadSelectedSession()
+	m.ensureCursorVisible()
+}
 
-	// Restore selection
-	if selectedID != "" {
-		for i, node := range m.FlatNodes {
-			if node.ID == selectedID {
-				m.Cursor = i
-				m.Selected = node
-				break
-			}
+// restoreSelection reselects the node with the given ID after a rebuild.
+// If it is gone the cursor stays at the same row, clamped to the tree.
+func (m *Model) restoreSelection(selectedID string) {
+	for i, node := range m.FlatNodes {
+		if node.ID == selectedID {
+			m.Cursor = i
+			m.Selected = node
+			return
 		}
 	}
-	m.loadSelectedSession()
-	m.ensureCursorVisible()
+	m.Selected = nil
+	if len(m.FlatNodes) > 0 {
+		m.Cursor = max(0, min(m.Cursor, len(m.FlatNodes)-1))
+		m.Selected = m.FlatNodes[m.Cursor]
+	}
 }
 
 // sortProjects sorts the projects list based on current SortMode
@@ -358,52 +525,158 @@ func (m *Model) sortProjects() {
 
 // UpdateDetailContentHeight recalculates the total content height
 func (m *Model) UpdateDetailContentHeight() {
-	messages := m.getSelectedMessages()
-	if len(messages) == 0 {
+	entries := m.getSelectedEntries()
+	if len(entries) == 0 {
 		m.DetailContentHeight = 0
 		return
 	}
 
-	// Calculate available width for content (matches view.go calculation)
+	totalLines := 0
+	for _, e := range entries {
+		totalLines += m.entryLineCount(e)
+	}
+	m.DetailContentHeight = totalLines
+}
+
+// detailMaxWidth returns the available width for detail content (matches view.go calculation)
+func (m *Model) detailMaxWidth() int {
 	maxWidth := m.Width - m.TreeWidth - 8
 	if maxWidth < 20 {
 		maxWidth = 20
 	}
+	return maxWidth
+}
 
-	// Count lines including wrapped lines
-	totalLines := 0
-	for _, msg := range messages {
-		// Header line
-		totalLines++
-
-		if m.BlockExpanded[msg.UUID] {
-			// Expanded: count actual lines in blocks with wrapping
-			for _, block := range msg.Blocks {
-				var content string
-				switch block.Type {
-				case "text":
-					content = block.Text
-					totalLines++ // label line
-				case "thinking":
-					content = block.Thinking
-					totalLines++ // label line
-				case "tool_use":
-					content = block.ToolInput
-					totalLines += 2 // tool name + label
-				case "tool_result":
-					content = block.Result
-					totalLines += 2 // label lines
+// entryLineCount estimates how many detail pane lines an entry takes,
+// including the blank separator line
+func (m *Model) entryLineCount(e data.Entry) int {
+	maxWidth := m.detailMaxWidth()
+
+	msg, ok := e.(*data.Message)
+	if !ok {
+		// Event row, plus its details when expanded
+		totalLines := 2
+		if ev, ok := e.(data.Event); ok && m.BlockExpanded[e.EntryID()] {
+			if details := m.Redact(ev.Details()); details != "" {
+				totalLines += countWrappedLines(details, maxWidth-6)
+			}
+		}
+		return totalLines
+	}
+
+	// Header line
+	totalLines := 1
+	if msg.OutputTokens > 0 || msg.InputTokens > 0 || msg.CacheReadTokens > 0 {
+		totalLines++ // token usage line
+	}
+
+	if m.BlockExpanded[msg.UUID] {
+		// Expanded: count actual lines in blocks with wrapping
+		for _, block := range msg.Blocks {
+			var content string
+			switch block.Type {
+			case "text":
+				content = m.Redact(block.Text)
+				totalLines++ // label line
+			case "thinking":
+				content = m.Redact(block.Thinking)
+				totalLines++ // label line
+			case "tool_use":
+				content = m.Redact(block.ToolInput)
+				totalLines += 2 // tool name + label
+				if block.Call != nil && block.Call.Result != nil {
+					totalLines++ // result label
+					totalLines += countWrappedLines(m.Redact(block.Call.Result.Result), maxWidth-6)
+				}
+			case "tool_result":
+				if block.Call != nil {
+					totalLines += 2 // one-line reference to the paired call
+					continue
 				}
-				// Count lines with wrapping estimation
-				totalLines += countWrappedLines(content, maxWidth-6) // indent
+				content = m.Redact(block.Result)
+				totalLines += 2 // label lines
 			}
-		} else {
-			// Collapsed: just preview line
-			totalLines++
+			// Count lines with wrapping estimation
+			totalLines += countWrappedLines(content, maxWidth-6) // indent
 		}
-		totalLines++ // blank line between messages
+	} else {
+		// Collapsed: just preview line
+		totalLines++
+	}
+	totalLines++ // blank line between messages
+	return totalLines
+}
+
+// entryAtScroll returns the index of the entry at the detail scroll
+// position, along with the first line of every entry
+func (m *Model) entryAtScroll(entries []data.Entry) (int, []int) {
+	current := m.DetailScroll
+	if current > m.DetailContentHeight {
+		current = m.DetailContentHeight
+	}
+	starts := make([]int, len(entries))
+	line, currentIdx := 0, len(entries)
+	for i, e := range entries {
+		starts[i] = line
+		n := m.entryLineCount(e)
+		if currentIdx == len(entries) && line+n > current {
+			currentIdx = i
+		}
+		line += n
+	}
+	return currentIdx, starts
+}
+
+// loadPayloadsAtScroll rereads the full content of oversized blocks in the
+// message at the scroll position, expanding it
+func (m *Model) loadPayloadsAtScroll() {
+	entries := m.getSelectedEntries()
+	idx, _ := m.entryAtScroll(entries)
+	if idx >= len(entries) {
+		return
+	}
+	msg, ok := entries[idx].(*data.Message)
+	if !ok {
+		return
+	}
+	for i := range msg.Blocks {
+		b := &msg.Blocks[i]
+		m.loadPayload(b)
+		if b.Call != nil && b.Call.Result != nil {
+			m.loadPayload(b.Call.Result)
+		}
+	}
+	m.BlockExpanded[msg.UUID] = true
+	m.UpdateDetailContentHeight()
+}
+
+// loadPayload reads the full content of a deferred block. If the session
+// file was truncated or rotated since, the preview stays and the status bar
+// says why.
+func (m *Model) loadPayload(b *data.ContentBlock) {
+	if err := b.LoadPayload(); err != nil {
+		m.notify("Cannot load full content: %v", err)
+	}
+}
+
+// jumpToFailedToolCall scrolls the detail pane to the next (dir > 0) or
+// previous (dir < 0) message holding a failed tool call, expanding it
+func (m *Model) jumpToFailedToolCall(dir int) {
+	entries := m.getSelectedEntries()
+	currentIdx, starts := m.entryAtScroll(entries)
+
+	for i := currentIdx + dir; i >= 0 && i < len(entries); i += dir {
+		msg, ok := entries[i].(*data.Message)
+		if !ok || !msg.HasFailedToolCall() {
+			continue
+		}
+		if !m.BlockExpanded[msg.UUID] {
+			m.BlockExpanded[msg.UUID] = true
+			m.UpdateDetailContentHeight()
+		}
+		m.DetailScroll = starts[i]
+		return
 	}
-	m.DetailContentHeight = totalLines
 }
 
 // countWrappedLines estimates how many display lines a string will take
@@ -432,20 +705,48 @@ func (m *Model) loadSelectedSession() {
 	if m.Selected == nil {
 		return
 	}
+	// Blocks shown on their own get their full payload
+	if m.Selected.Type == NodeBlock && m.Selected.Block != nil {
+		m.loadPayload(m.Selected.Block)
+		if call := m.Selected.Block.Call; call != nil && call.Result != nil {
+			m.loadPayload(call.Result)
+		}
+	}
 	if m.Selected.Type == NodeSession && m.Selected.Session != nil {
-		if len(m.Selected.Session.Messages) == 0 {
-			data.LoadSession(m.Selected.Session)
+		if !m.Selected.Session.Loaded() {
+			if !m.loadSession(m.Selected.Session) {
+				m.UpdateDetailContentHeight()
+				return
+			}
+			m.Selected.Label = sessionLabel(m.Selected.Session, m.Masker())
+			m.Selected.Children = sessionChildren(m.Selected.Session, m.Selected.Children, m.Masker())
+			m.refreshProjectLabels()
 		}
 		// Auto-expand new messages if in expand-all mode
 		if m.DetailExpandAll {
-			for _, msg := range m.Selected.Session.Messages {
-				m.BlockExpanded[msg.UUID] = true
+			for _, e := range m.Selected.Session.Entries {
+				m.BlockExpanded[e.EntryID()] = true
 			}
 		}
 		m.UpdateDetailContentHeight()
 	}
 }
 
+// loadSession loads the messages of an unloaded session, reporting
+// failures. Large sessions load in the background; it returns false for
+// those, and their nodes are rebuilt once the load arrives.
+func (m *Model) loadSession(session *data.Session) bool {
+	if m.loadInBackground(session) {
+		return false
+	}
+	if err := data.LoadSession(session); errors.Is(err, fs.ErrNotExist) {
+		session.Deleted = true
+	} else if err != nil {
+		m.notify("Cannot load session %s: %v", session.ID, err)
+	}
+	return true
+}
+
 // reloadExpandedSessions reloads messages for all expanded session nodes
 // This is needed after tree rebuild since session objects are recreated
 func (m *Model) reloadExpandedSessions() {
@@ -457,15 +758,11 @@ func (m *Model) reloadExpandedSessions() {
 func (m *Model) reloadExpandedSessionsRecursive(node *TreeNode) {
 	if node.Type == NodeSession && node.Expanded && node.Session != nil {
 		// Reload messages if not loaded
-		if len(node.Session.Messages) == 0 {
-			data.LoadSession(node.Session)
-		}
-		// Rebuild children from messages
-		if len(node.Children) == 0 && len(node.Session.Messages) > 0 {
-			for _, msg := range node.Session.Messages {
-				node.Children = append(node.Children, BuildMessageNode(msg))
-			}
+		if !node.Session.Loaded() && m.loadSession(node.Session) {
+			// Rebuild children from messages
+			node.Children = sessionChildren(node.Session, node.Children, m.Masker())
 		}
+		node.Label = sessionLabel(node.Session, m.Masker())
 	}
 	// Recurse into children (for expanded projects)
 	for _, child := range node.Children {
@@ -473,17 +770,82 @@ func (m *Model) reloadExpandedSessionsRecursive(node *TreeNode) {
 	}
 }
 
+// findSession returns the known session backed by the given file, if any
+func (m *Model) findSession(path string) *data.Session {
+	for _, p := range m.Projects {
+		for _, s := range p.Sessions {
+			if s.FilePath == path {
+				return s
+			}
+		}
+	}
+	return nil
+}
+
+// applySessionTail merges newly read lines into a session and its tree nodes
+func (m *Model) applySessionTail(session *data.Session, tail *data.SessionTail) {
+	session.ApplyTail(tail)
+
+	expanded := m.getExpandedIDs()
+	for _, node := range m.Tree {
+		m.applyTailToNode(node, session)
+	}
+	m.refreshProjectLabels()
+	m.restoreExpandedState(expanded)
+
+	// Keep the selection on the same node while the tree grows
+	var selectedID string
+	if m.Selected != nil {
+		selectedID = m.Selected.ID
+	}
+	m.flattenTree()
+	for i, node := range m.FlatNodes {
+		if node.ID == selectedID {
+			m.Cursor = i
+			m.Selected = node
+			break
+		}
+	}
+	m.ensureCursorVisible()
+
+	if m.Selected != nil && m.Selected.Session == session {
+		if m.DetailExpandAll {
+			for _, e := range session.Entries {
+				m.BlockExpanded[e.EntryID()] = true
+			}
+		}
+		m.UpdateDetailContentHeight()
+		if m.FollowMode {
+			m.scrollDetailToEnd()
+		}
+	}
+}
+
+func (m *Model) applyTailToNode(node *TreeNode, session *data.Session) {
+	if node.Type == NodeSession && node.Session == session {
+		node.Label = sessionLabel(session, m.Masker())
+		// Children are only built once the session has been expanded. New
+		// lines may extend any branch, so rebuild from the active path.
+		if len(node.Children) > 0 || node.Expanded {
+			node.Children = sessionChildren(session, node.Children, m.Masker())
+		}
+		return
+	}
+	if node.Type == NodeSession && node.Session != nil && node.Session == session.Parent {
+		node.Label = sessionLabel(node.Session, m.Masker()) // subagent costs roll up
+	}
+	for _, child := range node.Children {
+		m.applyTailToNode(child, session)
+	}
+}
+
 // rebuildSessionChildren rebuilds children nodes after loading messages
 func (m *Model) rebuildSessionChildren() {
 	if m.Selected == nil {
 		return
 	}
 	if m.Selected.Type == NodeSession && m.Selected.Session != nil {
-		if len(m.Selected.Children) == 0 && len(m.Selected.Session.Messages) > 0 {
-			for _, msg := range m.Selected.Session.Messages {
-				m.Selected.Children = append(m.Selected.Children, BuildMessageNode(msg))
-			}
-		}
+		m.Selected.Children = sessionChildren(m.Selected.Session, m.Selected.Children, m.Masker())
 	}
 }
 
@@ -0,0 +1,216 @@
+// Package redact masks secrets (API keys, tokens, passwords, private keys)
+// in transcript text before it is displayed
+package redact
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Mask replaces redacted text
+const Mask = "[redacted]"
+
+// detector finds one kind of secret. When group is set only that submatch
+// is masked, keeping e.g. the key name of KEY=value; valid, if set, rejects
+// matched values that aren't secrets.
+type detector struct {
+	name  string
+	re    *regexp.Regexp
+	group int
+	valid func(string) bool
+}
+
+// builtinDetectors cover common credential formats
+var builtinDetectors = []detector{
+	{name: "private key", re: regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?(?:-----END [A-Z ]*PRIVATE KEY-----|$)`)},
+	{name: "anthropic key", re: regexp.MustCompile(`\bsk-ant-[A-Za-z0-9_-]{16,}`)},
+	{name: "openai key", re: regexp.MustCompile(`\bsk-(?:proj-)?[A-Za-z0-9_-]{20,}`)},
+	{name: "aws access key", re: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{name: "github token", re: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{30,}|github_pat_[A-Za-z0-9_]{22,})`)},
+	{name: "slack token", re: regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`)},
+	{name: "google api key", re: regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}`)},
+	{name: "stripe key", re: regexp.MustCompile(`\b[rs]k_(?:live|test)_[0-9A-Za-z]{16,}`)},
+	{name: "jwt", re: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`)},
+	{name: "bearer token", re: regexp.MustCompile(`(?i)\bbearer\s+([A-Za-z0-9._~+/-]{16,}=*)`), group: 1},
+	{name: "url credentials", re: regexp.MustCompile(`://[^/\s:@]+:([^@\s/]+)@`), group: 1},
+	// KEY=value and key: value, as in .env files, shell exports and YAML
+	{name: "assignment", re: regexp.MustCompile(`(?i)\b[A-Z0-9_.-]*(?:api[_-]?key|token|secret|passw(?:or)?d|pwd|credentials?)[A-Z0-9_.-]*\s*[:=]\s*["']?([^\s"',;#]{4,})`), group: 1, valid: secretValue},
+	// "password": "value" in JSON
+	{name: "json field", re: regexp.MustCompile(`(?i)"[A-Za-z0-9_.-]*(?:api[_-]?key|token|secret|passw(?:or)?d|credentials?)[A-Za-z0-9_.-]*"\s*:\s*"([^"]{4,})"`), group: 1, valid: secretValue},
+}
+
+// candidateRe finds tokens checked for high entropy. Slashes are left out
+// so file paths don't qualify.
+var candidateRe = regexp.MustCompile(`[A-Za-z0-9+=_-]{24,}`)
+
+// entropyThreshold is the Shannon entropy, in bits per character, above
+// which a mixed-case alphanumeric token is treated as a secret. Hex digests
+// top out at 4 and don't qualify.
+const entropyThreshold = 4.2
+
+// Redactor masks secrets found by the built-in detectors, high-entropy
+// tokens and user patterns. A nil Redactor leaves text unchanged.
+type Redactor struct {
+	detectors []detector
+
+	mu    sync.Mutex
+	cache map[string]string
+}
+
+// cacheSize bounds memoised results; the detail pane redacts the same
+// blocks on every frame
+const cacheSize = 4096
+
+// New returns a redactor with the built-in detectors plus the given
+// regular expressions. Patterns with a capture group mask only the first
+// group.
+func New(patterns []string) (*Redactor, error) {
+	r := &Redactor{
+		detectors: append([]detector(nil), builtinDetectors...),
+		cache:     make(map[string]string),
+	}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("redaction pattern %q: %w", p, err)
+		}
+		group := 0
+		if re.NumSubexp() > 0 {
+			group = 1
+		}
+		r.detectors = append(r.detectors, detector{name: "user pattern", re: re, group: group})
+	}
+	return r, nil
+}
+
+// Redact returns s with secrets replaced by Mask
+func (r *Redactor) Redact(s string) string {
+	if r == nil || s == "" {
+		return s
+	}
+	r.mu.Lock()
+	if out, ok := r.cache[s]; ok {
+		r.mu.Unlock()
+		return out
+	}
+	r.mu.Unlock()
+
+	out := s
+	for _, d := range r.detectors {
+		out = d.apply(out)
+	}
+	out = candidateRe.ReplaceAllStringFunc(out, func(tok string) string {
+		if looksRandom(tok) {
+			return Mask
+		}
+		return tok
+	})
+
+	r.mu.Lock()
+	if len(r.cache) >= cacheSize {
+		r.cache = make(map[string]string)
+	}
+	r.cache[s] = out
+	r.mu.Unlock()
+	return out
+}
+
+func (d detector) apply(s string) string {
+	if d.group == 0 {
+		return d.re.ReplaceAllString(s, Mask)
+	}
+	var sb strings.Builder
+	last := 0
+	for _, m := range d.re.FindAllStringSubmatchIndex(s, -1) {
+		start, end := m[2*d.group], m[2*d.group+1]
+		if start < 0 || (d.valid != nil && !d.valid(s[start:end])) {
+			continue
+		}
+		sb.WriteString(s[last:start])
+		sb.WriteString(Mask)
+		last = end
+	}
+	if sb.Len() == 0 {
+		return s
+	}
+	sb.WriteString(s[last:])
+	return sb.String()
+}
+
+// secretValue rejects assignment values that are counts, flags or
+// placeholders, as in max_tokens: 4096 or token: null
+func secretValue(v string) bool {
+	switch strings.ToLower(v) {
+	case "true", "false", "null", "none", "undefined", Mask:
+		return false
+	}
+	return strings.Trim(v, "0123456789") != "" && !strings.HasPrefix(v, "$")
+}
+
+// looksRandom returns true for tokens mixing upper case, lower case and
+// digits with high per-character entropy
+func looksRandom(tok string) bool {
+	var upper, lower, digit bool
+	counts := make(map[rune]int)
+	for _, c := range tok {
+		switch {
+		case c >= 'A' && c <= 'Z':
+			upper = true
+		case c >= 'a' && c <= 'z':
+			lower = true
+		case c >= '0' && c <= '9':
+			digit = true
+		}
+		counts[c]++
+	}
+	if !upper || !lower || !digit {
+		return false
+	}
+	var entropy float64
+	n := float64(len(tok))
+	for _, c := range counts {
+		p := float64(c) / n
+		entropy -= p * math.Log2(p)
+	}
+	return entropy >= entropyThreshold
+}
+
+// DefaultPatternsPath returns the user pattern file under the user config
+// directory ($XDG_CONFIG_HOME/claude-mri on Linux)
+func DefaultPatternsPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "claude-mri", "redact.txt")
+}
+
+// LoadPatterns reads one regular expression per line, skipping blank lines
+// and # comments. A missing file yields no patterns.
+func LoadPatterns(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	return patterns, scanner.Err()
+}
@@ -0,0 +1,145 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/natdempk/claude-mri/internal/data"
+	"github.com/natdempk/claude-mri/internal/model"
+)
+
+var sparkLevels = []rune("▁▂▃▄▅▆▇█")
+
+// usageKind is one token kind in the analytics charts
+type usageKind struct {
+	name  string
+	glyph string
+	style lipgloss.Style
+	value func(data.TokenUsage) int
+}
+
+var usageKinds = []usageKind{
+	{"input", "█", lipgloss.NewStyle().Foreground(userColor), func(u data.TokenUsage) int { return u.Input }},
+	{"output", "█", lipgloss.NewStyle().Foreground(assistantColor), func(u data.TokenUsage) int { return u.Output }},
+	{"cache write", "▒", CompactionStyle.UnsetBold(), func(u data.TokenUsage) int { return u.CacheWrite }},
+	{"cache read", "░", EventStyle, func(u data.TokenUsage) int { return u.CacheRead }},
+}
+
+// renderAnalytics renders the token usage screen: a sparkline per token
+// kind and a stacked bar per period, newest last
+func renderAnalytics(m model.Model) string {
+	width := m.Width - 4
+	height := m.Height - 4
+
+	const labelWidth, valueWidth = 12, 8
+	rows := max(1, height-len(usageKinds)-5)
+	points := m.AnalyticsSeries(rows)
+
+	modelName := "all"
+	if m.Analytics.Model != "" {
+		modelName = formatModelName(m.Analytics.Model)
+	}
+	projectName := "all"
+	if p := m.AnalyticsProject(); p != nil {
+		projectName = p.Name
+	}
+
+	var lines []string
+	lines = append(lines, truncateWidth(fmt.Sprintf("Tokens per %s · model: %s · project: %s",
+		m.Analytics.Granularity, modelName, projectName), width), "")
+
+	// Sparklines over the same periods as the bars
+	sparkWidth := max(1, width-labelWidth-valueWidth-2)
+	sparkPoints := points
+	if len(sparkPoints) > sparkWidth {
+		sparkPoints = sparkPoints[len(sparkPoints)-sparkWidth:]
+	}
+	for _, k := range usageKinds {
+		values := make([]int, len(sparkPoints))
+		total := 0
+		for i, p := range sparkPoints {
+			values[i] = k.value(p.Usage)
+			total += values[i]
+		}
+		lines = append(lines, fmt.Sprintf("%-*s %s %*s", labelWidth, k.name,
+			k.style.Render(sparkline(values)), valueWidth, formatTokenCount(total)))
+	}
+	lines = append(lines, "")
+
+	// Stacked bars
+	maxTotal := 0
+	for _, p := range points {
+		maxTotal = max(maxTotal, p.Usage.Total())
+	}
+	barWidth := max(1, width-labelWidth-valueWidth-2)
+	for _, p := range points {
+		lines = append(lines, fmt.Sprintf("%-*s %s %*s", labelWidth, periodLabel(p, m.Analytics.Granularity),
+			stackedBar(p.Usage, maxTotal, barWidth), valueWidth, formatTokenCount(p.Usage.Total())))
+	}
+
+	var legend []string
+	for _, k := range usageKinds {
+		legend = append(legend, k.style.Render(k.glyph)+" "+k.name)
+	}
+	lines = append(lines, "", strings.Join(legend, "  "))
+	return strings.Join(lines, "\n")
+}
+
+// sparkline maps values onto block heights, leaving zeroes blank
+func sparkline(values []int) string {
+	peak := 0
+	for _, v := range values {
+		peak = max(peak, v)
+	}
+	var sb strings.Builder
+	for _, v := range values {
+		if v == 0 || peak == 0 {
+			sb.WriteRune(' ')
+			continue
+		}
+		level := v * (len(sparkLevels) - 1) / peak
+		sb.WriteRune(sparkLevels[level])
+	}
+	return sb.String()
+}
+
+// stackedBar draws usage as segments per token kind, scaled to peak
+func stackedBar(u data.TokenUsage, peak, width int) string {
+	if peak == 0 {
+		return strings.Repeat(" ", width)
+	}
+	var sb strings.Builder
+	drawn, cumulative := 0, 0
+	for _, k := range usageKinds {
+		cumulative += k.value(u)
+		// Round cumulative ends so segments add up to the scaled total
+		end := (cumulative*width + peak/2) / peak
+		if end > drawn {
+			sb.WriteString(k.style.Render(strings.Repeat(k.glyph, end-drawn)))
+			drawn = end
+		}
+	}
+	return sb.String() + strings.Repeat(" ", max(0, width-drawn))
+}
+
+func periodLabel(p data.UsagePoint, g data.Granularity) string {
+	switch g {
+	case data.Daily:
+		return p.Start.Format("Mon 01-02")
+	case data.Weekly:
+		return p.Start.Format("wk 01-02")
+	}
+	return p.Start.Format("01-02 15:04")
+}
+
+// formatTokenCount abbreviates token counts: 950, 12.3k, 4.5M
+func formatTokenCount(n int) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1e6)
+	case n >= 1_000:
+		return fmt.Sprintf("%.1fk", float64(n)/1e3)
+	}
+	return fmt.Sprintf("%d", n)
+}
@@ -4,11 +4,12 @@ import "github.com/charmbracelet/lipgloss"
 
 var (
 	// Colors
-	subtle     = lipgloss.AdaptiveColor{Light: "#D9DCCF", Dark: "#383838"}
-	highlight  = lipgloss.AdaptiveColor{Light: "#874BFD", Dark: "#7D56F4"}
-	active     = lipgloss.AdaptiveColor{Light: "#43BF6D", Dark: "#73F59F"}
-	userColor  = lipgloss.AdaptiveColor{Light: "#1E88E5", Dark: "#64B5F6"}
+	subtle         = lipgloss.AdaptiveColor{Light: "#D9DCCF", Dark: "#383838"}
+	highlight      = lipgloss.AdaptiveColor{Light: "#874BFD", Dark: "#7D56F4"}
+	active         = lipgloss.AdaptiveColor{Light: "#43BF6D", Dark: "#73F59F"}
+	userColor      = lipgloss.AdaptiveColor{Light: "#1E88E5", Dark: "#64B5F6"}
 	assistantColor = lipgloss.AdaptiveColor{Light: "#7B1FA2", Dark: "#CE93D8"}
+	errorColor     = lipgloss.AdaptiveColor{Light: "#D32F2F", Dark: "#EF5350"}
 
 	// Layout
 	BorderStyle = lipgloss.NewStyle().
@@ -16,8 +17,8 @@ var (
 			BorderForeground(subtle)
 
 	FocusedBorderStyle = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(highlight)
+				Border(lipgloss.RoundedBorder()).
+				BorderForeground(highlight)
 
 	// Header
 	HeaderStyle = lipgloss.NewStyle().
@@ -27,28 +28,28 @@ var (
 			Padding(0, 1)
 
 	// Tree pane
-	TreePaneStyle = BorderStyle.Width(40)
+	TreePaneStyle        = BorderStyle.Width(40)
 	TreePaneFocusedStyle = FocusedBorderStyle.Width(40)
 
 	// Detail pane
-	DetailPaneStyle = BorderStyle
+	DetailPaneStyle        = BorderStyle
 	DetailPaneFocusedStyle = FocusedBorderStyle
 
 	// Message styles
 	UserMessageStyle = lipgloss.NewStyle().
-			Foreground(userColor).
-			Bold(true)
+				Foreground(userColor).
+				Bold(true)
 
 	AssistantMessageStyle = lipgloss.NewStyle().
-			Foreground(assistantColor).
-			Bold(true)
+				Foreground(assistantColor).
+				Bold(true)
 
 	SelectedMessageStyle = lipgloss.NewStyle().
-			Background(lipgloss.Color("#3A3A3A"))
+				Background(lipgloss.Color("#3A3A3A"))
 
 	MessageHeaderStyle = lipgloss.NewStyle().
-			Bold(true).
-			MarginBottom(1)
+				Bold(true).
+				MarginBottom(1)
 
 	// Tree items
 	TreeItemStyle = lipgloss.NewStyle().
@@ -68,6 +69,31 @@ var (
 			Foreground(lipgloss.Color("#43BF6D")).
 			Bold(true)
 
+	ErrorStyle = lipgloss.NewStyle().
+			Foreground(errorColor).
+			Bold(true)
+
+	TreeErrorStyle = lipgloss.NewStyle().
+			PaddingLeft(1).
+			Foreground(errorColor)
+
+	TreeDeletedStyle = lipgloss.NewStyle().
+				PaddingLeft(1).
+				Foreground(subtle).
+				Strikethrough(true)
+
+	// Non-message entries (summaries, system lines, snapshots)
+	EventStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#888888"))
+
+	CompactionStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#E0A030")).
+			Bold(true)
+
+	BranchMarkerStyle = lipgloss.NewStyle().
+				Foreground(highlight).
+				Bold(true)
+
 	TokenStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("#888888")).
 			Italic(true)
@@ -78,8 +104,8 @@ var (
 			SetString("●")
 
 	InactiveIndicator = lipgloss.NewStyle().
-			Foreground(subtle).
-			SetString("○")
+				Foreground(subtle).
+				SetString("○")
 
 	FollowOnStyle = lipgloss.NewStyle().
 			Foreground(active).
@@ -92,4 +118,28 @@ var (
 	HelpStyle = lipgloss.NewStyle().
 			Foreground(subtle).
 			Padding(0, 1)
+
+	// Status bar, shown in place of the help line
+	StatusErrorStyle = lipgloss.NewStyle().
+				Foreground(errorColor).
+				Padding(0, 1)
+
+	StatusInfoStyle = lipgloss.NewStyle().
+			Foreground(active).
+			Padding(0, 1)
+
+	StatusProgressStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#E0A030")).
+				Padding(0, 1)
 )
+
+// SetTheme picks the light or dark variant of every color. "auto", the
+// default, asks the terminal for its background.
+func SetTheme(theme string) {
+	switch theme {
+	case "dark":
+		lipgloss.SetHasDarkBackground(true)
+	case "light":
+		lipgloss.SetHasDarkBackground(false)
+	}
+}
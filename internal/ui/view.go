@@ -2,12 +2,15 @@ package ui
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/natdempk/claude-mri/internal/data"
 	"github.com/natdempk/claude-mri/internal/debug"
 	"github.com/natdempk/claude-mri/internal/model"
+	"github.com/natdempk/claude-mri/internal/redact"
 )
 
 // View renders the UI
@@ -33,9 +36,25 @@ func View(m model.Model) string {
 	} else {
 		followStatus = FollowOffStyle.Render("[F]ollow: OFF")
 	}
+	branchStatus := m.BranchStatus()
+	if branchStatus != "" {
+		branchStatus = " " + branchStatus
+	}
+	if m.Redacting {
+		branchStatus += " [Redact]"
+	}
+	if m.Selected != nil && m.Selected.Type == model.NodeSession && m.Selected.Session != nil && m.Selected.Session.Deleted {
+		branchStatus += " [Deleted: x to dismiss]"
+	}
+	sessions, agents := m.ActiveCounts(time.Now())
+	live := InactiveIndicator
+	if sessions+agents > 0 {
+		live = ActiveIndicator
+	}
+	branchStatus += fmt.Sprintf(" %s %d live, %d agents", live, sessions, agents)
 	header := HeaderStyle.Render("claude-mri") +
-		"  " + focusIndicator + " " + sortIndicator +
-		strings.Repeat(" ", max(0, m.Width-35-len("claude-mri")-len(focusIndicator)-len(sortIndicator))) +
+		"  " + focusIndicator + " " + sortIndicator + branchStatus +
+		strings.Repeat(" ", max(0, m.Width-35-len("claude-mri")-len(focusIndicator)-len(sortIndicator)-lipgloss.Width(branchStatus))) +
 		followStatus
 
 	// Tree pane
@@ -45,6 +64,7 @@ func View(m model.Model) string {
 		treePaneStyle = TreePaneFocusedStyle
 	}
 	treePane := treePaneStyle.
+		Width(m.TreeWidth).
 		Height(m.Height - 4).
 		Render(treeContent)
 
@@ -62,14 +82,36 @@ func View(m model.Model) string {
 	// Combine panes
 	body := lipgloss.JoinHorizontal(lipgloss.Top, treePane, detailPane)
 
-	// Help bar
-	help := HelpStyle.Render("Tab:switch  j/k:nav  Enter:expand  s:sort  f:follow  q:quit")
+	// Help bar, replaced by the status bar while there is news
+	help := HelpStyle.Render("Tab:switch  j/k:nav  Enter:expand  e:next error  b:branch  a/u:agent in/out  D:diagnostics  t:tokens  r:redact  w:export  !:errors  s:sort  f:follow  q:quit")
+	if status := renderStatus(m); status != "" {
+		help = status
+	}
+
+	// The token usage screen replaces both panes
+	if m.Analytics.Open {
+		body = FocusedBorderStyle.
+			Width(m.Width - 2).
+			Height(m.Height - 4).
+			Render(renderAnalytics(m))
+		help = HelpStyle.Render("g:hour/day/week  m:model  p:project  t/Esc:close  q:quit")
+	}
+
+	// So does the error history
+	if m.Status.HistoryOpen {
+		body = FocusedBorderStyle.
+			Width(m.Width - 2).
+			Height(m.Height - 4).
+			Render(renderHistory(m))
+		help = HelpStyle.Render("j/k:scroll  !/Esc:close  q:quit")
+	}
 
 	return lipgloss.JoinVertical(lipgloss.Left, header, body, help)
 }
 
 func renderTree(m model.Model) string {
 	var sb strings.Builder
+	now := time.Now()
 
 	visibleHeight := m.TreeHeight()
 	startIdx := m.TreeScroll
@@ -94,12 +136,30 @@ func renderTree(m model.Model) string {
 			}
 		}
 
+		// Live indicator; colored only on plain rows, since its styling
+		// would reset a row's own colors
+		live := ""
+		if active, ok := model.NodeActivity(node, now); ok {
+			dot := InactiveIndicator
+			if active {
+				dot = ActiveIndicator
+			}
+			live = dot.Value() + " "
+			if i != m.Cursor && !node.IsError && (node.Session == nil || !node.Session.Deleted) {
+				live = dot.String() + " "
+			}
+		}
+
 		// Label
-		label := indent + indicator + node.Label
+		label := indent + indicator + live + node.Label
 
 		// Style based on selection
 		if i == m.Cursor {
 			label = SelectedStyle.Render(label)
+		} else if node.IsError {
+			label = TreeErrorStyle.Render(label)
+		} else if node.Session != nil && node.Session.Deleted && node.Type == model.NodeSession {
+			label = TreeDeletedStyle.Render(label)
 		} else {
 			label = TreeItemStyle.Render(label)
 		}
@@ -112,6 +172,9 @@ func renderTree(m model.Model) string {
 }
 
 func renderConversation(m model.Model) string {
+	if m.ShowDiagnostics {
+		return renderDiagnostics(m)
+	}
 	if m.Selected == nil {
 		return "Select a session to view conversation"
 	}
@@ -125,8 +188,11 @@ func renderConversation(m model.Model) string {
 	if m.Selected.Session == nil {
 		return "No session data"
 	}
-	messages := m.Selected.Session.Messages
-	if len(messages) == 0 {
+	if size, ok := m.IsLoading(m.Selected.Session); ok {
+		return StatusProgressStyle.Render(fmt.Sprintf("Loading session (%.1f MB)...", float64(size)/(1<<20)))
+	}
+	entries := m.DetailEntries()
+	if len(entries) == 0 {
 		return "No messages in session"
 	}
 
@@ -136,9 +202,17 @@ func renderConversation(m model.Model) string {
 	if maxWidth < 20 {
 		maxWidth = 20
 	}
-	for _, msg := range messages {
-		isExpanded := m.BlockExpanded[msg.UUID]
-		msgStr := renderMessage(msg, false, isExpanded, maxWidth)
+	for _, entry := range entries {
+		isExpanded := m.BlockExpanded[entry.EntryID()]
+		var msgStr string
+		switch e := entry.(type) {
+		case *data.Message:
+			msgStr = renderMessage(e, false, isExpanded, maxWidth, m.Masker())
+		case data.Event:
+			msgStr = renderEvent(e, isExpanded, maxWidth, m.Masker())
+		default:
+			continue
+		}
 		// Split and truncate each line to prevent layout breakage
 		for _, line := range strings.Split(msgStr, "\n") {
 			if lipgloss.Width(line) > maxWidth {
@@ -173,9 +247,19 @@ func renderConversation(m model.Model) string {
 	// Build output with fixed indicator lines
 	var result strings.Builder
 
-	// Top indicator (always present)
+	// Top indicator (always present); unparsed lines or the session
+	// environment when at the top
+	session := m.Selected.Session
 	if startLine > 0 {
 		result.WriteString(fmt.Sprintf("  ↑ %d lines above", startLine))
+	} else if len(session.Diagnostics) > 0 {
+		warning := fmt.Sprintf("  ⚠ %d parse diagnostic(s), D to list", len(session.Diagnostics))
+		if n := session.BadLines(); n > 0 {
+			warning = fmt.Sprintf("  ⚠ %d line(s) could not be parsed, D to list", n)
+		}
+		result.WriteString(ErrorStyle.Render(truncateWidth(warning, maxWidth)))
+	} else if env := sessionEnvironment(m.Selected.Session); env != "" {
+		result.WriteString(TokenStyle.Render(truncateWidth("  "+env, maxWidth)))
 	}
 	result.WriteString("\n")
 
@@ -193,7 +277,7 @@ func renderConversation(m model.Model) string {
 	return result.String()
 }
 
-func renderMessage(msg *data.Message, isSelected, isExpanded bool, maxWidth int) string {
+func renderMessage(msg *data.Message, isSelected, isExpanded bool, maxWidth int, r *redact.Redactor) string {
 	var sb strings.Builder
 
 	// Message header with icon and type
@@ -256,12 +340,12 @@ func renderMessage(msg *data.Message, isSelected, isExpanded bool, maxWidth int)
 	if isExpanded {
 		// Show all blocks
 		for _, block := range msg.Blocks {
-			sb.WriteString(renderBlockFull(&block, maxWidth))
+			sb.WriteString(renderBlockFull(&block, maxWidth, r))
 			sb.WriteString("\n")
 		}
 	} else {
 		// Show preview
-		preview := getMessagePreview(msg, maxWidth-4)
+		preview := getMessagePreview(msg, maxWidth-4, r)
 		sb.WriteString("   " + preview)
 	}
 
@@ -279,7 +363,52 @@ func renderMessage(msg *data.Message, isSelected, isExpanded bool, maxWidth int)
 	return result
 }
 
-func renderBlockFull(b *data.ContentBlock, maxWidth int) string {
+// renderEvent renders a non-message entry as a distinct inline row
+func renderEvent(e data.Event, isExpanded bool, maxWidth int, r *redact.Redactor) string {
+	style := EventStyle
+	icon := "•"
+	switch e := e.(type) {
+	case *model.BranchMarker:
+		return BranchMarkerStyle.Render(truncateWidth(e.Title, maxWidth))
+	case *data.SummaryEntry:
+		icon = "📋"
+	case *data.SystemEntry:
+		icon = "⚙"
+		if e.IsCompaction() {
+			icon = "⟳"
+			style = CompactionStyle
+		}
+		if e.IsError() {
+			style = ErrorStyle
+		}
+	case *data.FileHistorySnapshotEntry:
+		icon = "📸"
+	case *data.QueueOperationEntry:
+		icon = "⏳"
+	case *data.UnknownEntry:
+		icon = "?"
+	}
+
+	header := icon + " " + r.Redact(e.Describe())
+	if t := e.EntryTime(); t.Year() > 1 {
+		header += fmt.Sprintf(" (%s)", t.Format("15:04:05"))
+	}
+
+	var sb strings.Builder
+	sb.WriteString(style.Render(truncateWidth(header, maxWidth)))
+	if isExpanded {
+		if details := r.Redact(e.Details()); details != "" {
+			for _, line := range strings.Split(details, "\n") {
+				for _, wl := range wrapLine(line, maxWidth-6) {
+					sb.WriteString("\n   " + EventStyle.Render(wl))
+				}
+			}
+		}
+	}
+	return sb.String()
+}
+
+func renderBlockFull(b *data.ContentBlock, maxWidth int, r *redact.Redactor) string {
 	var sb strings.Builder
 	indent := "   "
 
@@ -287,7 +416,7 @@ func renderBlockFull(b *data.ContentBlock, maxWidth int) string {
 	case "thinking":
 		sb.WriteString(indent + ThinkingStyle.Render("💭 [thinking]"))
 		sb.WriteString("\n")
-		for _, line := range strings.Split(b.Thinking, "\n") {
+		for _, line := range strings.Split(r.Redact(b.Thinking), "\n") {
 			wrapped := wrapLine(line, maxWidth-6)
 			for _, wl := range wrapped {
 				sb.WriteString(indent + ThinkingStyle.Render(wl) + "\n")
@@ -296,88 +425,335 @@ func renderBlockFull(b *data.ContentBlock, maxWidth int) string {
 
 	case "text":
 		// Wrap and display text
-		wrapped := wrapText(b.Text, maxWidth-4)
+		wrapped := wrapText(r.Redact(b.Text), maxWidth-4)
 		for _, line := range wrapped {
 			sb.WriteString(indent + line + "\n")
 		}
 
 	case "tool_use":
-		sb.WriteString(indent + ToolNameStyle.Render("🔧 "+b.ToolName))
+		if b.Failed() {
+			sb.WriteString(indent + ErrorStyle.Render("❌ "+b.Call.Summary(r)))
+		} else if b.Call != nil {
+			sb.WriteString(indent + ToolNameStyle.Render("🔧 "+b.Call.Summary(r)))
+		} else {
+			sb.WriteString(indent + ToolNameStyle.Render("🔧 "+b.ToolName))
+		}
 		sb.WriteString("\n")
 		if b.ToolInput != "" {
-			for _, line := range strings.Split(b.ToolInput, "\n") {
+			for _, line := range strings.Split(r.Redact(b.ToolInput), "\n") {
 				wrapped := wrapLine(line, maxWidth-6)
 				for _, wl := range wrapped {
 					sb.WriteString(indent + wl + "\n")
 				}
 			}
 		}
+		// Show the paired result as part of the call
+		if b.Call != nil && b.Call.Result != nil {
+			writeResult(&sb, b.Call.Result, indent, maxWidth, r)
+		}
 
 	case "tool_result":
-		sb.WriteString(indent + "📤 [tool result]")
-		sb.WriteString("\n")
-		if b.Result != "" {
-			for _, line := range strings.Split(b.Result, "\n") {
-				wrapped := wrapLine(line, maxWidth-6)
-				for _, wl := range wrapped {
-					sb.WriteString(indent + wl + "\n")
-				}
+		if b.Call != nil {
+			// Full result is shown under the tool_use
+			ref := "📤 ↳ " + b.Call.Summary(r)
+			if b.IsError {
+				ref = ErrorStyle.Render(ref)
 			}
+			sb.WriteString(indent + ref)
+			sb.WriteString("\n")
+			break
 		}
+		writeResult(&sb, b, indent, maxWidth, r)
 	}
 
+	// Paired results note this where they are rendered (writeResult)
+	if b.Lazy != nil && !(b.Type == "tool_result" && b.Call != nil) {
+		sb.WriteString(indent + lazyNotice(b.Lazy) + "\n")
+	}
 	return sb.String()
 }
 
-func getMessagePreview(msg *data.Message, maxWidth int) string {
+// lazyNotice tells how much of a deferred payload is not shown
+func lazyNotice(l *data.LazyPayload) string {
+	return TokenStyle.Render(fmt.Sprintf("… %d KiB, %d lines not loaded (p to load)", l.Size/1024, l.Lines))
+}
+
+// writeResult renders a tool_result block's label and content,
+// in the error style if the tool reported a failure
+func writeResult(sb *strings.Builder, b *data.ContentBlock, indent string, maxWidth int, r *redact.Redactor) {
+	label := "📤 [tool result]"
+	if b.IsError {
+		label = ErrorStyle.Render("❌ [tool error]")
+	}
+	sb.WriteString(indent + label)
+	sb.WriteString("\n")
+	if b.Result == "" {
+		return
+	}
+	for _, line := range strings.Split(r.Redact(b.Result), "\n") {
+		wrapped := wrapLine(line, maxWidth-6)
+		for _, wl := range wrapped {
+			if b.IsError {
+				wl = ErrorStyle.Render(wl)
+			}
+			sb.WriteString(indent + wl + "\n")
+		}
+	}
+	// Paired results are rendered under their tool_use, so note it here
+	if b.Lazy != nil && b.Call != nil {
+		sb.WriteString(indent + lazyNotice(b.Lazy) + "\n")
+	}
+}
+
+func getMessagePreview(msg *data.Message, maxWidth int, r *redact.Redactor) string {
 	for _, b := range msg.Blocks {
 		switch b.Type {
 		case "text":
 			if b.Text != "" {
-				preview := strings.ReplaceAll(b.Text, "\n", " ")
+				preview := strings.ReplaceAll(r.Redact(b.Text), "\n", " ")
 				return truncateWidth(preview, maxWidth)
 			}
 		case "thinking":
 			return ThinkingStyle.Render("[thinking...]")
 		case "tool_use":
+			if b.Failed() {
+				return ErrorStyle.Render("❌ " + b.ToolName + "()")
+			}
 			return ToolNameStyle.Render("🔧 " + b.ToolName + "()")
 		case "tool_result":
+			if b.Call != nil {
+				if b.IsError {
+					return ErrorStyle.Render("↳ " + b.Call.Summary(r))
+				}
+				return "↳ " + b.Call.Summary(r)
+			}
+			if b.IsError {
+				return ErrorStyle.Render("[tool error]")
+			}
 			return "[tool result]"
 		}
 	}
 	return "(empty)"
 }
 
+// renderDiagnostics lists parse diagnostics for the selection, grouped by
+// project or session file
+func renderDiagnostics(m model.Model) string {
+	maxWidth := m.Width - m.TreeWidth - 8
+	groups := m.SelectedDiagnostics()
+	if len(groups) == 0 {
+		return "No parse diagnostics"
+	}
+
+	var lines []string
+	lines = append(lines, HeaderStyle.Render("Parse diagnostics"), "")
+	for _, g := range groups {
+		lines = append(lines, truncateWidth(g.Title, maxWidth))
+		for _, d := range g.Diagnostics {
+			style := TokenStyle
+			if d.Kind == data.DiagMalformed || d.Kind == data.DiagUnreadable {
+				style = ErrorStyle
+			}
+			lines = append(lines, style.Render(truncateWidth("  "+d.String(), maxWidth)))
+		}
+		lines = append(lines, "")
+	}
+
+	visibleHeight := m.Height - 6
+	start := min(m.DetailScroll, max(0, len(lines)-visibleHeight))
+	end := min(len(lines), start+visibleHeight)
+	return strings.Join(lines[start:end], "\n")
+}
+
+// renderStatus shows the latest error, or else the outcome of the last
+// action, while it is fresh, and the progress of background scans and
+// loads. It is empty when there's nothing to say.
+func renderStatus(m model.Model) string {
+	var progress []string
+	if scan := m.Status.Scan; scan != nil {
+		progress = append(progress, fmt.Sprintf("Scanning projects %d/%d", scan.Done, scan.Total))
+	}
+	if n := len(m.Status.Loading); n > 0 {
+		progress = append(progress, fmt.Sprintf("Loading %d session(s)", n))
+	}
+	status := ""
+	if len(progress) > 0 {
+		status = StatusProgressStyle.Render(strings.Join(progress, " · "))
+	}
+
+	if notice, ok := m.CurrentNotice(time.Now()); ok {
+		text := notice.Text
+		if notice.Count > 1 {
+			text += fmt.Sprintf(" (x%d)", notice.Count)
+		}
+		// Truncate before styling so escape codes aren't cut
+		text = truncateWidth("✗ "+text, m.Width-lipgloss.Width(status)-16) + "  !:history"
+		status = StatusErrorStyle.Render(text) + status
+	} else if info, ok := m.CurrentInfo(time.Now()); ok {
+		status = StatusInfoStyle.Render(truncateWidth("✓ "+info.Text, m.Width-lipgloss.Width(status)-4)) + status
+	}
+	return status
+}
+
+// renderHistory lists past errors, newest first
+func renderHistory(m model.Model) string {
+	notices := m.Status.Notices
+	if len(notices) == 0 {
+		return "No errors"
+	}
+
+	lines := []string{HeaderStyle.Render(fmt.Sprintf("Errors (%d)", len(notices))), ""}
+	visibleHeight := m.Height - 8
+	start := len(notices) - 1 - m.Status.Scroll
+	for i := start; i >= 0 && len(lines)-2 < visibleHeight; i-- {
+		n := notices[i]
+		line := n.Time.Format("15:04:05") + "  " + n.Text
+		if n.Count > 1 {
+			line += fmt.Sprintf(" (x%d)", n.Count)
+		}
+		lines = append(lines, ErrorStyle.Render(truncateWidth(line, m.Width-6)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderDailyCosts lists the most recent days with spend, newest first
+func renderDailyCosts(days map[string]float64) string {
+	keys := make([]string, 0, len(days))
+	for day, cost := range days {
+		if cost > 0 {
+			keys = append(keys, day)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(keys)))
+	if len(keys) > 7 {
+		keys = keys[:7]
+	}
+	var sb strings.Builder
+	for _, day := range keys {
+		sb.WriteString(TokenStyle.Render(fmt.Sprintf("  %s  %s", day, data.FormatCost(days[day]))) + "\n")
+	}
+	return sb.String()
+}
+
 func renderNodeInfo(m model.Model) string {
 	var sb strings.Builder
 
 	switch m.Selected.Type {
 	case model.NodeProject:
-		sb.WriteString(fmt.Sprintf("Project: %s\n", m.Selected.Label))
-		sb.WriteString(fmt.Sprintf("Path: %s\n", m.Selected.ID))
+		sb.WriteString(fmt.Sprintf("Project: %s\n", m.Selected.Project.Name))
+		if p := m.Selected.Project; p != nil && p.Cwd != "" {
+			sb.WriteString(fmt.Sprintf("Path: %s\n", p.Cwd))
+		}
+		if p := m.Selected.Project; p != nil && len(m.Roots) > 1 {
+			sb.WriteString(fmt.Sprintf("Source: %s\n", p.Source))
+		}
+		sb.WriteString(fmt.Sprintf("Folder: %s\n", m.Selected.ID))
+		if p := m.Selected.Project; p != nil && p.Archive != "" {
+			sb.WriteString(fmt.Sprintf("Archive: %s (read-only)\n", p.Archive))
+		}
 		sb.WriteString(fmt.Sprintf("Sessions: %d\n", len(m.Selected.Children)))
+		if p := m.Selected.Project; p != nil {
+			if cost := p.Cost(); cost > 0 {
+				sb.WriteString(fmt.Sprintf("Cost: %s\n", data.FormatCost(cost)))
+				sb.WriteString(renderDailyCosts(p.DailyCosts()))
+			}
+			sb.WriteString("\n")
+			for _, s := range p.Sessions {
+				if s.IsAgent {
+					continue
+				}
+				sb.WriteString(fmt.Sprintf("  %s  %s\n", truncateWidth(s.ID, 11), sessionEnvironment(s)))
+				if sum := s.Summary; sum != nil {
+					line := fmt.Sprintf("    %d msgs, %d→%d tokens", sum.Messages(), sum.InputTokens+sum.CacheReadTokens+sum.CacheWriteTokens, sum.OutputTokens)
+					if cost := s.TotalCost(); cost > 0 {
+						line += ", " + data.FormatCost(cost)
+					}
+					if !sum.LastTimestamp.IsZero() {
+						line += ", " + sum.LastTimestamp.Local().Format("2006-01-02 15:04")
+					}
+					if sum.FirstPrompt != "" {
+						line += "  " + strings.ReplaceAll(m.Redact(sum.FirstPrompt), "\n", " ")
+					}
+					sb.WriteString(TokenStyle.Render(truncateWidth(line, m.Width-m.TreeWidth-8)) + "\n")
+				}
+			}
+		}
 
 	case model.NodeMessage:
 		if m.Selected.Message != nil {
 			msg := m.Selected.Message
 			sb.WriteString(fmt.Sprintf("Type: %s\n", msg.Type))
-			sb.WriteString(fmt.Sprintf("Time: %s\n\n", msg.Timestamp.Format("15:04:05")))
+			sb.WriteString(fmt.Sprintf("Time: %s\n", msg.Timestamp.Format("15:04:05")))
+			if msg.Cwd != "" {
+				sb.WriteString(fmt.Sprintf("Cwd: %s\n", msg.Cwd))
+			}
+			if msg.GitBranch != "" {
+				sb.WriteString(fmt.Sprintf("Branch: %s\n", msg.GitBranch))
+			}
+			if msg.Version != "" {
+				sb.WriteString(fmt.Sprintf("Version: %s\n", msg.Version))
+			}
+			sb.WriteString("\n")
 
 			for _, block := range msg.Blocks {
-				sb.WriteString(renderBlockFull(&block, m.Width-m.TreeWidth-8))
+				sb.WriteString(renderBlockFull(&block, m.Width-m.TreeWidth-8, m.Masker()))
 				sb.WriteString("\n")
 			}
 		}
 
+	case model.NodeSource:
+		sb.WriteString(fmt.Sprintf("Source: %s\n", m.Selected.Label))
+		for _, r := range m.Roots {
+			if r.Label == m.Selected.Label {
+				sb.WriteString(fmt.Sprintf("Path: %s\n", r.Path))
+			}
+		}
+		sb.WriteString(fmt.Sprintf("Projects: %d\n", len(m.Selected.Children)))
+		var cost float64
+		for _, child := range m.Selected.Children {
+			if child.Project != nil {
+				cost += child.Project.Cost()
+			}
+		}
+		if cost > 0 {
+			sb.WriteString(fmt.Sprintf("Cost: %s\n", data.FormatCost(cost)))
+		}
+
+	case model.NodeEvent:
+		if m.Selected.Event != nil {
+			ev := m.Selected.Event
+			sb.WriteString(fmt.Sprintf("Type: %s\n", ev.EntryType()))
+			if t := ev.EntryTime(); t.Year() > 1 {
+				sb.WriteString(fmt.Sprintf("Time: %s\n", t.Format("15:04:05")))
+			}
+			sb.WriteString("\n" + m.Redact(ev.Describe()) + "\n\n")
+			sb.WriteString(m.Redact(ev.Details()))
+		}
+
 	case model.NodeBlock:
 		if m.Selected.Block != nil {
-			sb.WriteString(renderBlockFull(m.Selected.Block, m.Width-m.TreeWidth-8))
+			sb.WriteString(renderBlockFull(m.Selected.Block, m.Width-m.TreeWidth-8, m.Masker()))
 		}
 	}
 
 	return sb.String()
 }
 
+// sessionEnvironment describes where a session ran: branch, CLI version, cwd
+func sessionEnvironment(s *data.Session) string {
+	var parts []string
+	if s.GitBranch != "" {
+		parts = append(parts, "⎇ "+s.GitBranch)
+	}
+	if s.Version != "" {
+		parts = append(parts, "v"+s.Version)
+	}
+	if s.Cwd != "" {
+		parts = append(parts, s.Cwd)
+	}
+	return strings.Join(parts, " · ")
+}
+
 func truncateWidth(s string, maxWidth int) string {
 	if maxWidth <= 3 {
 		return "..."
@@ -496,7 +872,7 @@ func formatModelName(model string) string {
 }
 
 // formatTokenUsage creates compact token usage display
-// Format: "Tokens: 12940 cached + 14703 uncached -> 3"
+// Format: "Tokens: 12940 cached + 14703 uncached -> 3 · $0.06"
 func formatTokenUsage(msg *data.Message) string {
 	uncached := msg.InputTokens + msg.CacheWriteTokens
 	cached := msg.CacheReadTokens
@@ -510,9 +886,12 @@ func formatTokenUsage(msg *data.Message) string {
 		parts = append(parts, fmt.Sprintf("%d uncached", uncached))
 	}
 
-	if len(parts) == 0 {
-		return fmt.Sprintf("→ %d tokens", out)
+	line := fmt.Sprintf("→ %d tokens", out)
+	if len(parts) > 0 {
+		line = fmt.Sprintf("Tokens: %s → %d", strings.Join(parts, " + "), out)
 	}
-
-	return fmt.Sprintf("Tokens: %s → %d", strings.Join(parts, " + "), out)
+	if cost, ok := msg.Cost(); ok {
+		line += " · " + data.FormatCost(cost)
+	}
+	return line
 }
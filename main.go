@@ -1,11 +1,14 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"os"
-	"path/filepath"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/natdempk/claude-mri/internal/config"
+	"github.com/natdempk/claude-mri/internal/data"
 	"github.com/natdempk/claude-mri/internal/debug"
 	"github.com/natdempk/claude-mri/internal/model"
 	"github.com/natdempk/claude-mri/internal/ui"
@@ -26,15 +29,40 @@ func (m mainModel) View() string {
 }
 
 func main() {
+	cfg, err := config.Parse(os.Args[1:], os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+
 	// Initialize debug logging
-	home, _ := os.UserHomeDir()
-	logPath := filepath.Join(home, "claude-mri-debug.log")
-	if err := debug.Init(logPath); err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: could not init debug log: %v\n", err)
+	if logPath := cfg.DebugLogPath(); logPath != "" {
+		if err := debug.Init(logPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not init debug log: %v\n", err)
+		}
+		defer debug.Close()
+	}
+
+	ui.SetTheme(cfg.Theme)
+	if interval, _ := cfg.PollInterval(); interval > 0 {
+		data.ForcePolling = true
+		data.PollInterval = interval
+	}
+
+	opts := model.Options{
+		Roots:     cfg.Roots(),
+		Follow:    cfg.FollowMode(),
+		TreeWidth: cfg.Width(),
+		Redact:    cfg.Redacting(),
+	}
+	if cfg.SortRecent() {
+		opts.SortMode = model.SortRecent
 	}
-	defer debug.Close()
 
-	m := mainModel{Model: model.NewModel()}
+	m := mainModel{Model: model.NewModel(opts)}
 	p := tea.NewProgram(m, tea.WithAltScreen())
 	if _, err := p.Run(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
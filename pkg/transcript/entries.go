@@ -0,0 +1,106 @@
+package transcript
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"time"
+
+	"github.com/natdempk/claude-mri/internal/data"
+)
+
+// Entry is one line of a transcript. Streamed chunks of a response are
+// separate entries here; Session.Messages merges them.
+type Entry struct {
+	Line      int // 1-based line number in the file
+	Type      string
+	ID        string // uuid, or the best stable identifier the entry has
+	Timestamp time.Time
+	Message   *Message        // for user and assistant entries
+	Raw       json.RawMessage // the line as written
+}
+
+// LineError reports a line that could not be parsed. Iteration continues
+// after it.
+type LineError struct {
+	Line int
+	Err  error
+}
+
+func (e *LineError) Error() string { return fmt.Sprintf("line %d: %v", e.Line, e.Err) }
+func (e *LineError) Unwrap() error { return e.Err }
+
+// Entries streams the entries of a transcript file, which may be .gz or
+// .zst compressed. Malformed lines yield a *LineError and iteration
+// continues; other errors end it.
+func Entries(path string) iter.Seq2[Entry, error] {
+	return func(yield func(Entry, error) bool) {
+		file, err := data.OpenSessionFile(path)
+		if err != nil {
+			yield(Entry{}, err)
+			return
+		}
+		defer file.Close()
+		decode(file, 0, true, yield)
+	}
+}
+
+// Decode streams the entries of a transcript read from r
+func Decode(r io.Reader) iter.Seq2[Entry, error] {
+	return func(yield func(Entry, error) bool) {
+		decode(r, 0, true, yield)
+	}
+}
+
+// decode yields entries from r, numbering lines after line. A final line
+// without a newline is only consumed if atEOF or if it is already valid
+// JSON, since it may still be being written. It returns the bytes and lines
+// consumed.
+func decode(r io.Reader, line int, atEOF bool, yield func(Entry, error) bool) (consumed int64, lines int) {
+	reader := bufio.NewReaderSize(r, 64*1024)
+	for {
+		raw, err := reader.ReadBytes('\n')
+		if len(raw) > 0 {
+			trimmed := bytes.TrimSpace(raw)
+			if raw[len(raw)-1] != '\n' && !atEOF && !json.Valid(trimmed) {
+				return consumed, lines
+			}
+			consumed += int64(len(raw))
+			lines++
+			if len(trimmed) > 0 {
+				entry, perr := parseEntry(trimmed, line+lines)
+				if !yield(entry, perr) {
+					return consumed, lines
+				}
+			}
+		}
+		if err == io.EOF {
+			return consumed, lines
+		}
+		if err != nil {
+			yield(Entry{}, err)
+			return consumed, lines
+		}
+	}
+}
+
+func parseEntry(raw []byte, line int) (Entry, error) {
+	entry := Entry{Line: line, Raw: json.RawMessage(raw)}
+	parsed, err := data.ParseEntryLine(raw)
+	if err != nil {
+		return entry, &LineError{Line: line, Err: err}
+	}
+	if parsed == nil {
+		return entry, nil
+	}
+	entry.Type = parsed.EntryType()
+	entry.ID = parsed.EntryID()
+	entry.Timestamp = parsed.EntryTime()
+	if msg, ok := parsed.(*data.Message); ok {
+		entry.Message = convertMessage(msg)
+	}
+	return entry, nil
+}
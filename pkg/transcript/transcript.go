@@ -0,0 +1,194 @@
+// Package transcript reads Claude Code session transcripts: the JSONL files
+// under ~/.claude/projects. It exposes the parsing the claude-mri TUI uses
+// behind a small, stable API.
+//
+// Scan lists projects and sessions, Session.Messages loads a session with
+// streamed response chunks merged, Entries streams the raw entries of a file
+// and Watch follows entries as they are appended.
+package transcript
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/natdempk/claude-mri/internal/data"
+)
+
+// Project is a folder of sessions, one per working directory
+type Project struct {
+	Name     string // short display name, unique among the scanned projects
+	Path     string // project folder
+	Cwd      string // working directory the sessions ran in
+	Sessions []*Session
+}
+
+// Session is one transcript file: a main session or a subagent
+type Session struct {
+	ID        string
+	Path      string
+	IsAgent   bool
+	AgentID   string
+	ParentID  string // for agents, the session that spawned them
+	Subagents []*Session
+	UpdatedAt time.Time
+	Cwd       string
+	GitBranch string
+	Version   string
+
+	session *data.Session
+}
+
+// Message is a user or assistant turn
+type Message struct {
+	UUID         string
+	ParentUUID   string
+	Type         string // "user" | "assistant"
+	Timestamp    time.Time
+	SessionID    string
+	AgentID      string
+	IsSidechain  bool
+	Cwd          string
+	GitBranch    string
+	Version      string
+	RequestID    string
+	APIMessageID string // shared by streamed chunks of one response
+	Model        string
+	StopReason   string
+	Usage        Usage
+	Blocks       []ContentBlock
+}
+
+// ContentBlock is one block of a message's content
+type ContentBlock struct {
+	Type      string // "text" | "thinking" | "tool_use" | "tool_result" | ...
+	Text      string
+	Thinking  string
+	ToolName  string          // for tool_use
+	ToolInput json.RawMessage // for tool_use
+	ToolID    string          // tool_use id, or tool_use_id for tool_result
+	Result    string          // for tool_result, flattened to text
+	IsError   bool            // tool_result reported a failure
+}
+
+// Usage counts the tokens of an assistant message
+type Usage struct {
+	Input      int
+	Output     int
+	CacheRead  int
+	CacheWrite int
+}
+
+// DefaultRoot returns ~/.claude/projects
+func DefaultRoot() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".claude", "projects")
+}
+
+// Scan lists the projects and sessions under root without reading
+// transcripts beyond their first lines
+func Scan(root string) ([]*Project, error) {
+	projects, err := data.ScanProjects(root)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*Project, len(projects))
+	for i, p := range projects {
+		out[i] = convertProject(p)
+	}
+	return out, nil
+}
+
+// Messages reads the session and returns its messages, with streamed chunks
+// of one response merged into a single message
+func (s *Session) Messages() ([]*Message, error) {
+	if err := data.LoadSession(s.session); err != nil {
+		return nil, err
+	}
+	out := make([]*Message, len(s.session.Messages))
+	for i, msg := range s.session.Messages {
+		// Large payloads are only previewed by default; callers get all of it
+		for j := range msg.Blocks {
+			if err := msg.Blocks[j].LoadPayload(); err != nil {
+				return nil, err
+			}
+		}
+		out[i] = convertMessage(msg)
+	}
+	return out, nil
+}
+
+func convertProject(p *data.Project) *Project {
+	out := &Project{Name: p.Name, Path: p.Path, Cwd: p.Cwd}
+	bySession := make(map[*data.Session]*Session, len(p.Sessions))
+	for _, s := range p.Sessions {
+		cs := &Session{
+			ID:        s.ID,
+			Path:      s.FilePath,
+			IsAgent:   s.IsAgent,
+			AgentID:   s.AgentID,
+			ParentID:  s.ParentSessionID,
+			UpdatedAt: s.UpdatedAt,
+			Cwd:       s.Cwd,
+			GitBranch: s.GitBranch,
+			Version:   s.Version,
+			session:   s,
+		}
+		bySession[s] = cs
+		out.Sessions = append(out.Sessions, cs)
+	}
+	for _, s := range p.Sessions {
+		for _, agent := range s.Subagents {
+			if ca := bySession[agent]; ca != nil {
+				bySession[s].Subagents = append(bySession[s].Subagents, ca)
+			}
+		}
+	}
+	return out
+}
+
+func convertMessage(m *data.Message) *Message {
+	out := &Message{
+		UUID:         m.UUID,
+		Type:         m.Type,
+		Timestamp:    m.Timestamp,
+		SessionID:    m.SessionID,
+		IsSidechain:  m.IsSidechain,
+		Cwd:          m.Cwd,
+		GitBranch:    m.GitBranch,
+		Version:      m.Version,
+		RequestID:    m.RequestID,
+		APIMessageID: m.APIMessageID(),
+		Model:        m.Model,
+		StopReason:   m.StopReason,
+		Usage: Usage{
+			Input:      m.InputTokens,
+			Output:     m.OutputTokens,
+			CacheRead:  m.CacheReadTokens,
+			CacheWrite: m.CacheWriteTokens,
+		},
+		Blocks: make([]ContentBlock, len(m.Blocks)),
+	}
+	if m.ParentUUID != nil {
+		out.ParentUUID = *m.ParentUUID
+	}
+	if m.AgentID != nil {
+		out.AgentID = *m.AgentID
+	}
+	for i, b := range m.Blocks {
+		out.Blocks[i] = ContentBlock{
+			Type:     b.Type,
+			Text:     b.Text,
+			Thinking: b.Thinking,
+			ToolName: b.ToolName,
+			ToolID:   b.ToolID,
+			Result:   b.Result,
+			IsError:  b.IsError,
+		}
+		if b.ToolInput != "" {
+			out.Blocks[i].ToolInput = json.RawMessage(b.ToolInput)
+		}
+	}
+	return out
+}
@@ -0,0 +1,200 @@
+package transcript
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const fixture = `{"type":"user","uuid":"u1","sessionId":"s1","message":{"role":"user","content":"list files"}}
+{"type":"assistant","uuid":"a1","parentUuid":"u1","message":{"id":"msg_1","role":"assistant","model":"claude-sonnet-4-20250514","content":[{"type":"tool_use","id":"t1","name":"Bash","input":{"command":"ls"}}],"usage":{"input_tokens":10,"output_tokens":3}}}
+not json
+{"type":"assistant","uuid":"a2","parentUuid":"a1","message":{"id":"msg_1","role":"assistant","model":"claude-sonnet-4-20250514","content":"done","usage":{"input_tokens":10,"output_tokens":5}}}
+{"type":"summary","summary":"Listing files","leafUuid":"a2"}
+`
+
+func TestDecode(t *testing.T) {
+	var entries []Entry
+	var lineErr *LineError
+	for e, err := range Decode(strings.NewReader(fixture)) {
+		if err != nil {
+			if !errors.As(err, &lineErr) {
+				t.Fatalf("unexpected error %v", err)
+			}
+			continue
+		}
+		entries = append(entries, e)
+	}
+	if lineErr == nil || lineErr.Line != 3 {
+		t.Errorf("expected line 3 reported, got %v", lineErr)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("expected 4 entries, got %d", len(entries))
+	}
+	if entries[1].Message == nil || entries[1].Message.Blocks[0].ToolName != "Bash" || entries[1].Message.APIMessageID != "msg_1" {
+		t.Errorf("unexpected tool message %+v", entries[1].Message)
+	}
+	if entries[3].Type != "summary" || entries[3].Message != nil || entries[3].Line != 5 {
+		t.Errorf("unexpected summary entry %+v", entries[3])
+	}
+}
+
+func TestEntries_Compressed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "3f1c2a9e-0000-4000-8000-000000000001.jsonl.gz")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(file)
+	gz.Write([]byte(fixture))
+	gz.Close()
+	file.Close()
+
+	var ids []string
+	for e, err := range Entries(path) {
+		if err == nil {
+			ids = append(ids, e.ID)
+		}
+	}
+	if strings.Join(ids, ",") != "u1,a1,a2,summary:a2" {
+		t.Errorf("expected the decompressed entries, got %v", ids)
+	}
+}
+
+func TestScanAndMessages(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "-src-app")
+	os.MkdirAll(dir, 0o755)
+	if err := os.WriteFile(filepath.Join(dir, "3f1c2a9e-0000-4000-8000-000000000001.jsonl"), []byte(fixture), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	projects, err := Scan(root)
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if len(projects) != 1 || len(projects[0].Sessions) != 1 {
+		t.Fatalf("expected one project with one session, got %+v", projects)
+	}
+	msgs, err := projects[0].Sessions[0].Messages()
+	if err != nil {
+		t.Fatalf("Messages: %v", err)
+	}
+	// The two chunks of msg_1 are merged into one turn
+	if len(msgs) != 2 || len(msgs[1].Blocks) != 2 || msgs[1].Usage.Output != 5 {
+		t.Errorf("expected merged assistant turn, got %d messages", len(msgs))
+	}
+}
+
+func appendLines(t *testing.T, path, lines string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(lines); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func nextChange(t *testing.T, w *Watcher) Change {
+	t.Helper()
+	select {
+	case change := <-w.Changes:
+		return change
+	case err := <-w.Errors:
+		t.Fatalf("watch error: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a change")
+	}
+	return Change{}
+}
+
+// waitClosed fails unless the watcher's goroutine closes both channels
+func waitClosed(t *testing.T, w *Watcher) {
+	t.Helper()
+	deadline := time.After(5 * time.Second)
+	for changes, errs := w.Changes, w.Errors; changes != nil || errs != nil; {
+		select {
+		case _, ok := <-changes:
+			if !ok {
+				changes = nil
+			}
+		case _, ok := <-errs:
+			if !ok {
+				errs = nil
+			}
+		case <-deadline:
+			t.Fatal("channels not closed after Close")
+		}
+	}
+}
+
+func TestWatch(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "-src-app")
+	os.MkdirAll(dir, 0o755)
+	path := filepath.Join(dir, "3f1c2a9e-0000-4000-8000-000000000001.jsonl")
+	if err := os.WriteFile(path, []byte(fixture), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := Watch(root)
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+
+	// Existing content is skipped; appended lines arrive numbered on
+	appendLines(t, path, `{"type":"user","uuid":"u2","parentUuid":"a2","message":{"role":"user","content":"and hidden ones?"}}`+"\n")
+	change := nextChange(t, w)
+	if change.Path != path || change.Project != dir || change.SessionID != "3f1c2a9e-0000-4000-8000-000000000001" || change.Reset {
+		t.Errorf("unexpected change %+v", change)
+	}
+	if len(change.Entries) != 1 || change.Entries[0].ID != "u2" || change.Entries[0].Line != 6 {
+		t.Fatalf("expected entry u2 on line 6, got %+v", change.Entries)
+	}
+
+	// New transcripts are read from the start
+	agent := filepath.Join(dir, "agent-abc123.jsonl")
+	appendLines(t, agent, `{"type":"user","uuid":"g1","message":{"role":"user","content":"survey"}}`+"\n")
+	change = nextChange(t, w)
+	if change.Path != agent || len(change.Entries) != 1 || change.Entries[0].Line != 1 {
+		t.Errorf("expected the new agent transcript from line 1, got %+v", change)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	waitClosed(t, w)
+	if err := w.Close(); err != nil {
+		t.Fatalf("second Close: %v", err)
+	}
+}
+
+func TestWatch_CloseWhileUndrained(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "-src-app")
+	os.MkdirAll(dir, 0o755)
+
+	w, err := Watch(root)
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+
+	// Fill the Changes buffer so the watcher blocks delivering, then stop it
+	for i := range 20 {
+		path := filepath.Join(dir, fmt.Sprintf("agent-%06x.jsonl", i))
+		appendLines(t, path, `{"type":"user","uuid":"u1","message":{"role":"user","content":"hi"}}`+"\n")
+	}
+	time.Sleep(time.Second)
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	waitClosed(t, w)
+}
@@ -0,0 +1,271 @@
+package transcript
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/natdempk/claude-mri/internal/data"
+)
+
+// Change is a batch of entries appended to one transcript file
+type Change struct {
+	Path      string
+	Project   string // project folder
+	SessionID string // file name without .jsonl and compression suffix; agent-<id> for subagents
+	Reset     bool   // the file was truncated or replaced; Entries start at line 1
+	Entries   []Entry
+	Errors    []error // lines that could not be parsed, as *LineError
+}
+
+// Watcher follows the transcripts under a root directory. Files that exist
+// when watching starts are followed from their current end; new files are
+// read from the start.
+type Watcher struct {
+	// Changes receives appended entries. It must be drained; the watcher
+	// blocks rather than dropping changes.
+	Changes <-chan Change
+	// Errors receives watch and read errors
+	Errors <-chan error
+
+	watcher *data.Watcher
+	root    string
+	changes chan Change
+	errors  chan error
+	files   map[string]*followedFile
+	done    chan struct{}
+	close   sync.Once
+}
+
+type followedFile struct {
+	info    os.FileInfo
+	offset  int64
+	lines   int  // lines before offset
+	counted bool // lines has been counted for a file that predates watching
+}
+
+// Watch starts following the transcripts under root
+func Watch(root string) (*Watcher, error) {
+	dw, err := data.NewWatcher(root)
+	if err != nil {
+		return nil, err
+	}
+	w := &Watcher{
+		watcher: dw,
+		root:    root,
+		changes: make(chan Change, 16),
+		errors:  make(chan error, 16),
+		files:   make(map[string]*followedFile),
+		done:    make(chan struct{}),
+	}
+	w.Changes = w.changes
+	w.Errors = w.errors
+
+	for _, path := range transcriptFiles(root) {
+		if info, err := os.Stat(path); err == nil {
+			w.files[path] = &followedFile{info: info, offset: info.Size()}
+		}
+	}
+	if err := dw.Start(); err != nil {
+		return nil, err
+	}
+	go w.run()
+	return w, nil
+}
+
+// Close stops watching and closes the channels. Closing again does nothing.
+func (w *Watcher) Close() error {
+	w.close.Do(func() {
+		close(w.done)
+		w.watcher.Stop()
+	})
+	return nil
+}
+
+func (w *Watcher) run() {
+	defer close(w.changes)
+	defer close(w.errors)
+	for {
+		select {
+		case events := <-w.watcher.Events:
+			for _, ev := range events {
+				if !w.handle(ev) {
+					return
+				}
+			}
+		case err := <-w.watcher.Errors:
+			if !w.sendError(err) {
+				return
+			}
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// handle follows one file change. New project directories may already hold
+// transcripts written before they were watched.
+func (w *Watcher) handle(ev data.FileEvent) bool {
+	switch {
+	case ev.Kind == data.ChangeRemoved || ev.Kind == data.ChangeRenamed:
+		for path := range w.files {
+			if path == ev.Path || (ev.IsProject && strings.HasPrefix(path, ev.Path+string(filepath.Separator))) {
+				delete(w.files, path)
+			}
+		}
+		return true
+	case ev.IsProject:
+		for _, path := range transcriptsIn(ev.Path) {
+			if !w.poll(path) {
+				return false
+			}
+		}
+		return true
+	}
+	return w.poll(ev.Path)
+}
+
+// poll reads what was appended to path since it was last seen. Compressed
+// files can't be appended to in place, so any change to one is read again
+// from the start. It returns false once the watcher is closed.
+func (w *Watcher) poll(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return w.sendError(err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return w.sendError(err)
+	}
+
+	f := w.files[path]
+	if f == nil {
+		f = &followedFile{counted: true}
+		w.files[path] = f
+	}
+	if f.info != nil && os.SameFile(f.info, info) && info.Size() == f.info.Size() && info.ModTime().Equal(f.info.ModTime()) {
+		return true
+	}
+
+	change := Change{
+		Path:      path,
+		Project:   projectDir(w.root, path),
+		SessionID: data.TrimSessionExt(filepath.Base(path)),
+	}
+	compressed := data.IsCompressed(path)
+	if f.info != nil && (compressed || !os.SameFile(f.info, info) || info.Size() < f.offset) {
+		change.Reset = true
+		*f = followedFile{counted: true}
+	}
+	if !f.counted {
+		f.lines, err = countLines(file, f.offset)
+		if err != nil {
+			return w.sendError(err)
+		}
+		f.counted = true
+	}
+	f.info = info
+
+	var r io.Reader = file
+	if compressed {
+		rc, err := data.OpenSessionFile(path)
+		if err != nil {
+			return w.sendError(err)
+		}
+		defer rc.Close()
+		r = rc
+	} else if _, err := file.Seek(f.offset, io.SeekStart); err != nil {
+		return w.sendError(err)
+	}
+	consumed, lines := decode(r, f.lines, compressed, func(e Entry, err error) bool {
+		if err != nil {
+			change.Errors = append(change.Errors, err)
+		} else {
+			change.Entries = append(change.Entries, e)
+		}
+		return true
+	})
+	f.offset += consumed
+	f.lines += lines
+
+	if len(change.Entries) == 0 && len(change.Errors) == 0 && !change.Reset {
+		return true
+	}
+	select {
+	case w.changes <- change:
+		return true
+	case <-w.done:
+		return false
+	}
+}
+
+func (w *Watcher) sendError(err error) bool {
+	select {
+	case w.errors <- err:
+		return true
+	case <-w.done:
+		return false
+	}
+}
+
+// countLines counts newlines in the first n bytes of file
+func countLines(file *os.File, n int64) (int, error) {
+	buf := make([]byte, 64*1024)
+	lines := 0
+	for off := int64(0); off < n; {
+		size := min(int64(len(buf)), n-off)
+		read, err := file.ReadAt(buf[:size], off)
+		lines += bytes.Count(buf[:read], []byte{'\n'})
+		off += int64(read)
+		if err != nil && err != io.EOF {
+			return 0, err
+		}
+		if read == 0 {
+			break
+		}
+	}
+	return lines, nil
+}
+
+// transcriptFiles lists session and subagent files under root
+func transcriptFiles(root string) []string {
+	var files []string
+	projects, _ := os.ReadDir(root)
+	for _, p := range projects {
+		if !p.IsDir() {
+			continue
+		}
+		dir := filepath.Join(root, p.Name())
+		files = append(files, transcriptsIn(dir)...)
+		subdirs, _ := filepath.Glob(filepath.Join(dir, "*", "subagents"))
+		for _, sub := range subdirs {
+			files = append(files, transcriptsIn(sub)...)
+		}
+	}
+	return files
+}
+
+// transcriptsIn lists the .jsonl files in dir, compressed or not
+func transcriptsIn(dir string) []string {
+	var files []string
+	matches, _ := filepath.Glob(filepath.Join(dir, "*.jsonl*"))
+	for _, path := range matches {
+		if strings.HasSuffix(path, ".jsonl") || data.IsCompressed(path) {
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+// projectDir returns the project folder a transcript belongs to
+func projectDir(root, path string) string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return filepath.Dir(path)
+	}
+	return filepath.Join(root, strings.Split(rel, string(filepath.Separator))[0])
+}